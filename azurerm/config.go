@@ -6,7 +6,10 @@ import (
 	"log"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -234,6 +237,48 @@ type ArmClient struct {
 	storageServiceClient storage.AccountsClient
 	storageUsageClient   storage.UsageClient
 
+	// storageContainersInUse tracks the Storage Containers managed during this run, so that
+	// multiple resources managing the same Container can be detected rather than fighting
+	storageContainersInUse   map[string]struct{}
+	storageContainersInUseMu sync.Mutex
+
+	// storageContainersRetrieved holds the Storage Containers retrieved by the Exists check, so that
+	// the Read which follows it during a refresh doesn't need to retrieve them again
+	storageContainersRetrieved   map[string]retrievedStorageContainer
+	storageContainersRetrievedMu sync.Mutex
+
+	// storageAccountsRetrieved holds the Storage Accounts retrieved during this run, so that reading many Storage
+	// Containers within the same Storage Account (e.g. during a refresh) only retrieves the Storage Account once
+	storageAccountsRetrieved   map[string]*retrievedStorageAccount
+	storageAccountsRetrievedMu sync.Mutex
+
+	// defaultStorageContainerAccessType is used when a Storage Container doesn't specify an access type
+	defaultStorageContainerAccessType string
+
+	// allowedStorageContainerAccessTypes restricts the access types Storage Containers can use, when specified
+	allowedStorageContainerAccessTypes []string
+
+	// storageContainerNameRegex is a regular expression which Storage Container names must match, when specified
+	storageContainerNameRegex string
+
+	// storageAPIVersion overrides the API Version (`x-ms-version`) used by the Storage data plane clients
+	storageAPIVersion string
+
+	// storageHTTPClient overrides the HTTP Client (and thus the transport timeout) used by the Blob Storage clients
+	storageHTTPClient *http.Client
+
+	// useSecondaryStorageEndpointOnRead retries failed Blob Storage reads against the secondary endpoint
+	useSecondaryStorageEndpointOnRead bool
+
+	// storageContainerCreatePollInterval is the time to wait between attempts to create a Storage Container
+	storageContainerCreatePollInterval time.Duration
+
+	// storageAccountLookupTimeout bounds listing the Storage Accounts within the Subscription, when specified
+	storageAccountLookupTimeout time.Duration
+
+	// storageStrictRead returns an error when a Storage Container isn't found, rather than removing it from the state
+	storageStrictRead bool
+
 	// Traffic Manager
 	trafficManagerGeographialHierarchiesClient trafficmanager.GeographicHierarchiesClient
 	trafficManagerProfilesClient               trafficmanager.ProfilesClient
@@ -367,6 +412,7 @@ func getArmClient(c *authentication.Config) (*ArmClient, error) {
 		environment:              env,
 		usingServicePrincipal:    c.ClientSecret != "",
 		skipProviderRegistration: c.SkipProviderRegistration,
+		storageContainersInUse:   make(map[string]struct{}),
 	}
 
 	oauthConfig, err := adal.NewOAuthConfig(env.ActiveDirectoryEndpoint, c.TenantID)
@@ -995,6 +1041,13 @@ func (c *ArmClient) registerServiceFabricClients(endpoint, subscriptionId string
 	c.serviceFabricClustersClient = clustersClient
 }
 
+// overrideStorageResourceManagerEndpoint points the Storage management clients (used to look up Storage
+// Accounts and their Access Keys) at a custom Resource Manager endpoint, e.g. in air-gapped environments
+func (c *ArmClient) overrideStorageResourceManagerEndpoint(endpoint string) {
+	c.storageServiceClient.BaseURI = endpoint
+	c.storageUsageClient.BaseURI = endpoint
+}
+
 func (c *ArmClient) registerStorageClients(endpoint, subscriptionId string, auth autorest.Authorizer) {
 	accountsClient := storage.NewAccountsClientWithBaseURI(endpoint, subscriptionId)
 	c.configureClient(&accountsClient.Client, auth)
@@ -1042,8 +1095,15 @@ func (c *ArmClient) registerPolicyClients(endpoint, subscriptionId string, auth
 var (
 	storageKeyCacheMu sync.RWMutex
 	storageKeyCache   = make(map[string]string)
+
+	// storageKeyNameCache contains the name of the key (e.g. `key1`) in storageKeyCache
+	storageKeyNameCache = make(map[string]string)
 )
 
+// storageAccountKeysTimeout bounds the retries made when retrieving the Access Keys for a Storage Account. This is a
+// variable so that the tests can use a shorter timeout.
+var storageAccountKeysTimeout = 5 * time.Minute
+
 func (armClient *ArmClient) getKeyForStorageAccount(ctx context.Context, resourceGroupName, storageAccountName string) (string, bool, error) {
 	cacheIndex := resourceGroupName + "/" + storageAccountName
 	storageKeyCacheMu.RLock()
@@ -1058,7 +1118,12 @@ func (armClient *ArmClient) getKeyForStorageAccount(ctx context.Context, resourc
 	defer storageKeyCacheMu.Unlock()
 	key, ok = storageKeyCache[cacheIndex]
 	if !ok {
-		accountKeys, err := armClient.storageServiceClient.ListKeys(ctx, resourceGroupName, storageAccountName)
+		// throttled requests are retried by the Sender until the context is cancelled, so we bound the lookup to
+		// ensure a persistent failure surfaces as an error rather than blocking indefinitely
+		listKeysCtx, cancel := context.WithTimeout(ctx, storageAccountKeysTimeout)
+		defer cancel()
+
+		accountKeys, err := armClient.storageServiceClient.ListKeys(listKeysCtx, resourceGroupName, storageAccountName)
 		if utils.ResponseWasNotFound(accountKeys.Response) {
 			return "", false, nil
 		}
@@ -1084,12 +1149,149 @@ func (armClient *ArmClient) getKeyForStorageAccount(ctx context.Context, resourc
 
 		key = *keyPtr
 		storageKeyCache[cacheIndex] = key
+
+		keyName := "key1"
+		if keys[0].KeyName != nil {
+			keyName = *keys[0].KeyName
+		}
+		storageKeyNameCache[cacheIndex] = keyName
 	}
 
 	return key, true, nil
 }
 
+// retrievedStorageAccount is the cache entry for a single Storage Account, which is locked independently of the
+// other entries so that retrieving one Storage Account doesn't block retrieving another
+type retrievedStorageAccount struct {
+	mu      sync.Mutex
+	account *storage.Account
+}
+
+// getStorageAccountUsingCache retrieves the Storage Account, using the copy retrieved earlier in this run when available.
+// The entry for the Storage Account is locked whilst it's retrieved so that concurrent callers only retrieve it once.
+func (armClient *ArmClient) getStorageAccountUsingCache(ctx context.Context, resourceGroupName, storageAccountName string) (storage.Account, error) {
+	cacheIndex := resourceGroupName + "/" + storageAccountName
+
+	armClient.storageAccountsRetrievedMu.Lock()
+	if armClient.storageAccountsRetrieved == nil {
+		armClient.storageAccountsRetrieved = make(map[string]*retrievedStorageAccount)
+	}
+	entry, ok := armClient.storageAccountsRetrieved[cacheIndex]
+	if !ok {
+		entry = &retrievedStorageAccount{}
+		armClient.storageAccountsRetrieved[cacheIndex] = entry
+	}
+	armClient.storageAccountsRetrievedMu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.account != nil {
+		return *entry.account, nil
+	}
+
+	// failures aren't cached, so that a subsequent caller retries the lookup
+	account, err := armClient.storageServiceClient.GetProperties(ctx, resourceGroupName, storageAccountName)
+	if err != nil {
+		return account, err
+	}
+	entry.account = &account
+
+	return account, nil
+}
+
+// checkStorageAccountResourceGroup returns an error if a Storage Account with this name exists within the Subscription,
+// but in a different Resource Group - which is likely to be a mistake in the configuration, rather than the Storage Account
+// not existing. Since this lists every Storage Account in the Subscription, it should only be used once the Storage Account
+// hasn't been found in the specified Resource Group.
+func (armClient *ArmClient) checkStorageAccountResourceGroup(ctx context.Context, resourceGroupName, storageAccountName string) error {
+	if armClient.storageAccountLookupTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, armClient.storageAccountLookupTimeout)
+		defer cancel()
+	}
+
+	accounts, err := armClient.storageServiceClient.List(ctx)
+	if err != nil {
+		return fmt.Errorf("Error listing Storage Accounts: %+v", err)
+	}
+
+	if accounts.Value == nil {
+		return nil
+	}
+
+	for _, account := range *accounts.Value {
+		if account.Name == nil || account.ID == nil || !strings.EqualFold(*account.Name, storageAccountName) {
+			continue
+		}
+
+		id, err := parseAzureResourceID(*account.ID)
+		if err != nil {
+			return fmt.Errorf("Error parsing Storage Account ID %q: %+v", *account.ID, err)
+		}
+
+		if !strings.EqualFold(id.ResourceGroup, resourceGroupName) {
+			return fmt.Errorf("Storage Account %q exists in Resource Group %q rather than %q - please check the `resource_group_name`", storageAccountName, id.ResourceGroup, resourceGroupName)
+		}
+	}
+
+	return nil
+}
+
+// storageAccountKeySource returns the name of the credential used to access the Storage Account's data plane
+// (such as `key1`), or an empty string if the Storage Account hasn't been accessed by this Provider
+func (armClient *ArmClient) storageAccountKeySource(resourceGroupName, storageAccountName string) string {
+	storageKeyCacheMu.RLock()
+	defer storageKeyCacheMu.RUnlock()
+
+	return storageKeyNameCache[resourceGroupName+"/"+storageAccountName]
+}
+
+// storageDataPlaneAPIVersion returns the API Version used by the Storage data plane clients, which
+// can be overridden in the Provider block to opt into newer behaviours of the Storage API
+func (armClient *ArmClient) storageDataPlaneAPIVersion() string {
+	if armClient.storageAPIVersion != "" {
+		return armClient.storageAPIVersion
+	}
+
+	return mainStorage.DefaultAPIVersion
+}
+
 func (armClient *ArmClient) getBlobStorageClientForStorageAccount(ctx context.Context, resourceGroupName, storageAccountName string) (*mainStorage.BlobStorageClient, bool, error) {
+	return armClient.getBlobStorageClientForStorageAccountWithEndpoint(ctx, resourceGroupName, storageAccountName, "")
+}
+
+// getBlobStorageClientForStorageAccountWithEndpoint returns a Blob Storage client which sends requests to
+// the specified endpoint (for example a Private Link endpoint) rather than the public one, when specified
+func (armClient *ArmClient) getBlobStorageClientForStorageAccountWithEndpoint(ctx context.Context, resourceGroupName, storageAccountName, endpoint string) (*mainStorage.BlobStorageClient, bool, error) {
+	return armClient.buildBlobStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName, endpoint, false)
+}
+
+// getReadOnlyBlobStorageClientForStorageAccountWithEndpoint returns a Blob Storage client whose reads are retried
+// against the secondary endpoint, when configured. Since the secondary can lag behind the primary, this client must
+// only be used where the results are read (e.g. to refresh the state) - and never to read values which are then
+// written back to the primary.
+func (armClient *ArmClient) getReadOnlyBlobStorageClientForStorageAccountWithEndpoint(ctx context.Context, resourceGroupName, storageAccountName, endpoint string) (*mainStorage.BlobStorageClient, bool, error) {
+	return armClient.buildBlobStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName, endpoint, true)
+}
+
+func (armClient *ArmClient) buildBlobStorageClientForStorageAccount(ctx context.Context, resourceGroupName, storageAccountName, endpoint string, readOnly bool) (*mainStorage.BlobStorageClient, bool, error) {
+	storageClient, accountExists, err := armClient.buildStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName, endpoint, readOnly)
+	if err != nil || !accountExists {
+		return nil, accountExists, err
+	}
+
+	blobClient := storageClient.GetBlobService()
+	return &blobClient, true, nil
+}
+
+// getStorageClientForStorageAccountWithEndpoint returns the Storage client used to build the Blob Storage client, for
+// the few operations which need to send requests which the SDK can't represent
+func (armClient *ArmClient) getStorageClientForStorageAccountWithEndpoint(ctx context.Context, resourceGroupName, storageAccountName, endpoint string) (*mainStorage.Client, bool, error) {
+	return armClient.buildStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName, endpoint, false)
+}
+
+func (armClient *ArmClient) buildStorageClientForStorageAccount(ctx context.Context, resourceGroupName, storageAccountName, endpoint string, readOnly bool) (*mainStorage.Client, bool, error) {
 	key, accountExists, err := armClient.getKeyForStorageAccount(ctx, resourceGroupName, storageAccountName)
 	if err != nil {
 		return nil, accountExists, err
@@ -1099,13 +1301,160 @@ func (armClient *ArmClient) getBlobStorageClientForStorageAccount(ctx context.Co
 	}
 
 	storageClient, err := mainStorage.NewClient(storageAccountName, key, armClient.environment.StorageEndpointSuffix,
-		mainStorage.DefaultAPIVersion, true)
+		armClient.storageDataPlaneAPIVersion(), true)
 	if err != nil {
 		return nil, true, fmt.Errorf("Error creating storage client for storage storeAccount %q: %s", storageAccountName, err)
 	}
 
-	blobClient := storageClient.GetBlobService()
-	return &blobClient, true, nil
+	if armClient.storageHTTPClient != nil {
+		storageClient.HTTPClient = armClient.storageHTTPClient
+	}
+
+	storageClient.Sender = &storageThrottlingSender{
+		sender:   storageClient.Sender,
+		attempts: storageThrottlingRetryAttempts,
+	}
+
+	if endpoint != "" {
+		sender, err := newStorageEndpointOverrideSender(endpoint, storageClient.Sender)
+		if err != nil {
+			return nil, true, fmt.Errorf("Error configuring the endpoint for storage storeAccount %q: %s", storageAccountName, err)
+		}
+		storageClient.Sender = sender
+	} else if readOnly && armClient.useSecondaryStorageEndpointOnRead {
+		// custom endpoints don't have a known secondary, so this only applies to the public endpoint
+		storageClient.Sender = &storageSecondaryEndpointSender{
+			sender: storageClient.Sender,
+		}
+	}
+
+	return &storageClient, true, nil
+}
+
+// storageEndpointOverrideSender sends requests to a custom endpoint rather than the one built from the
+// Storage Account name & Environment. Since Shared Key signatures don't include the host, requests can be
+// redirected after they've been signed.
+type storageEndpointOverrideSender struct {
+	endpoint *url.URL
+	sender   mainStorage.Sender
+}
+
+func newStorageEndpointOverrideSender(endpoint string, sender mainStorage.Sender) (*storageEndpointOverrideSender, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing endpoint %q: %+v", endpoint, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("Endpoint %q must be an absolute URL", endpoint)
+	}
+
+	return &storageEndpointOverrideSender{
+		endpoint: u,
+		sender:   sender,
+	}, nil
+}
+
+func (s *storageEndpointOverrideSender) Send(c *mainStorage.Client, req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = s.endpoint.Scheme
+	req.URL.Host = s.endpoint.Host
+	req.Host = s.endpoint.Host
+
+	return s.sender.Send(c, req)
+}
+
+const (
+	storageThrottlingRetryAttempts = 5
+	storageThrottlingDefaultDelay  = 5 * time.Second
+	storageThrottlingMaxDelay      = 60 * time.Second
+)
+
+// storageThrottlingSender retries requests which are throttled (429) by the Storage service, waiting for the
+// duration specified in the `Retry-After` header. Other transient failures are retried by the wrapped Sender.
+type storageThrottlingSender struct {
+	sender   mainStorage.Sender
+	attempts int
+}
+
+func (s *storageThrottlingSender) Send(c *mainStorage.Client, req *http.Request) (*http.Response, error) {
+	// the body (for example the ACL in Set Container ACL) needs to be re-sent with each attempt
+	rr := autorest.NewRetriableRequest(req)
+
+	var resp *http.Response
+	var err error
+	for attempt := 0; attempt < s.attempts; attempt++ {
+		if err = rr.Prepare(); err != nil {
+			return resp, err
+		}
+
+		resp, err = s.sender.Send(c, rr.Request())
+		if resp == nil || resp.StatusCode != http.StatusTooManyRequests || attempt == s.attempts-1 {
+			return resp, err
+		}
+
+		delay := storageThrottlingRetryAfter(resp)
+		log.Printf("[DEBUG] Request to %q was throttled (attempt %d of %d) - retrying in %s", req.URL.Host, attempt+1, s.attempts, delay)
+		if resp.Body != nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+
+	return resp, err
+}
+
+// storageThrottlingRetryAfter returns how long to wait before retrying a throttled request
+func storageThrottlingRetryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return storageThrottlingDefaultDelay
+	}
+
+	delay := time.Duration(seconds) * time.Second
+	if delay > storageThrottlingMaxDelay {
+		return storageThrottlingMaxDelay
+	}
+
+	return delay
+}
+
+// storageSecondaryEndpointSender retries read requests which fail against the primary endpoint using the
+// secondary (`{account}-secondary`) endpoint, which is available for Read-Access Geo-Redundant accounts.
+// Writes are only ever sent to the primary endpoint.
+type storageSecondaryEndpointSender struct {
+	sender mainStorage.Sender
+}
+
+func (s *storageSecondaryEndpointSender) Send(c *mainStorage.Client, req *http.Request) (*http.Response, error) {
+	resp, err := s.sender.Send(c, req)
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return resp, err
+	}
+	if err == nil && resp.StatusCode < http.StatusInternalServerError {
+		return resp, err
+	}
+
+	hostSegments := strings.SplitN(req.URL.Host, ".", 2)
+	if len(hostSegments) != 2 || strings.HasSuffix(hostSegments[0], "-secondary") {
+		return resp, err
+	}
+
+	if resp != nil && resp.Body != nil {
+		resp.Body.Close()
+	}
+
+	secondaryURL := *req.URL
+	secondaryURL.Host = fmt.Sprintf("%s-secondary.%s", hostSegments[0], hostSegments[1])
+	log.Printf("[WARN] Read from the primary Storage endpoint %q failed - retrying against the secondary endpoint %q", req.URL.Host, secondaryURL.Host)
+
+	secondary := *req
+	secondary.URL = &secondaryURL
+	secondary.Host = secondaryURL.Host
+	return s.sender.Send(c, &secondary)
 }
 
 func (armClient *ArmClient) getFileServiceClientForStorageAccount(ctx context.Context, resourceGroupName, storageAccountName string) (*mainStorage.FileServiceClient, bool, error) {
@@ -1118,7 +1467,7 @@ func (armClient *ArmClient) getFileServiceClientForStorageAccount(ctx context.Co
 	}
 
 	storageClient, err := mainStorage.NewClient(storageAccountName, key, armClient.environment.StorageEndpointSuffix,
-		mainStorage.DefaultAPIVersion, true)
+		armClient.storageDataPlaneAPIVersion(), true)
 	if err != nil {
 		return nil, true, fmt.Errorf("Error creating storage client for storage storeAccount %q: %s", storageAccountName, err)
 	}
@@ -1137,7 +1486,7 @@ func (armClient *ArmClient) getTableServiceClientForStorageAccount(ctx context.C
 	}
 
 	storageClient, err := mainStorage.NewClient(storageAccountName, key, armClient.environment.StorageEndpointSuffix,
-		mainStorage.DefaultAPIVersion, true)
+		armClient.storageDataPlaneAPIVersion(), true)
 	if err != nil {
 		return nil, true, fmt.Errorf("Error creating storage client for storage storeAccount %q: %s", storageAccountName, err)
 	}
@@ -1156,7 +1505,7 @@ func (armClient *ArmClient) getQueueServiceClientForStorageAccount(ctx context.C
 	}
 
 	storageClient, err := mainStorage.NewClient(storageAccountName, key, armClient.environment.StorageEndpointSuffix,
-		mainStorage.DefaultAPIVersion, true)
+		armClient.storageDataPlaneAPIVersion(), true)
 	if err != nil {
 		return nil, true, fmt.Errorf("Error creating storage client for storage storeAccount %q: %s", storageAccountName, err)
 	}
@@ -0,0 +1,436 @@
+package azurerm
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2017-10-01/storage"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+func TestArmClientOverrideStorageResourceManagerEndpoint(t *testing.T) {
+	var requestedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/listKeys") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"keys":[{"keyName":"key1","value":"bW9jaw==","permissions":"Full"}]}`)
+	}))
+	defer server.Close()
+
+	client := &ArmClient{
+		storageServiceClient: storage.NewAccountsClientWithBaseURI("https://management.example.com", "00000000-0000-0000-0000-000000000000"),
+		storageUsageClient:   storage.NewUsageClientWithBaseURI("https://management.example.com", "00000000-0000-0000-0000-000000000000"),
+	}
+	client.overrideStorageResourceManagerEndpoint(server.URL)
+
+	if client.storageUsageClient.BaseURI != server.URL {
+		t.Fatalf("Expected the Usage Client to use %q but got %q", server.URL, client.storageUsageClient.BaseURI)
+	}
+
+	key, exists, err := client.getKeyForStorageAccount(context.Background(), "mock-resources", "mockendpointoverride")
+	if err != nil {
+		t.Fatalf("Error retrieving the key from the mock endpoint: %+v", err)
+	}
+	if !exists {
+		t.Fatalf("Expected the Storage Account to exist")
+	}
+	if key != "bW9jaw==" {
+		t.Fatalf("Expected the key %q but got %q", "bW9jaw==", key)
+	}
+
+	expectedPath := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/mock-resources/providers/Microsoft.Storage/storageAccounts/mockendpointoverride/listKeys"
+	if requestedPath != expectedPath {
+		t.Fatalf("Expected the request to %q but got %q", expectedPath, requestedPath)
+	}
+}
+
+func TestArmClientGetKeyForStorageAccountRetriesTransientFailures(t *testing.T) {
+	cases := []struct {
+		AccountName      string
+		StatusCodes      []int
+		ExpectedRequests int
+		ExpectError      bool
+		ExpectTimedOut   bool
+	}{
+		{
+			AccountName:      "mockthrottledonce",
+			StatusCodes:      []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK},
+			ExpectedRequests: 3,
+		},
+		{
+			AccountName:      "mockservererroronce",
+			StatusCodes:      []int{http.StatusServiceUnavailable, http.StatusOK},
+			ExpectedRequests: 2,
+		},
+		{
+			AccountName:      "mockservererror",
+			StatusCodes:      []int{http.StatusServiceUnavailable},
+			ExpectedRequests: 4,
+			ExpectError:      true,
+		},
+		{
+			// throttled requests are retried until storageAccountKeysTimeout is reached
+			AccountName:    "mockthrottledalways",
+			StatusCodes:    []int{http.StatusTooManyRequests},
+			ExpectError:    true,
+			ExpectTimedOut: true,
+		},
+	}
+
+	defer func(timeout time.Duration) {
+		storageAccountKeysTimeout = timeout
+	}(storageAccountKeysTimeout)
+	storageAccountKeysTimeout = 500 * time.Millisecond
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.AccountName)
+
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the last status code is repeated for any further requests
+			statusCode := tc.StatusCodes[len(tc.StatusCodes)-1]
+			if requests < len(tc.StatusCodes) {
+				statusCode = tc.StatusCodes[requests]
+			}
+			requests++
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(statusCode)
+			if statusCode == http.StatusOK {
+				fmt.Fprint(w, `{"keys":[{"keyName":"key1","value":"bW9jaw==","permissions":"Full"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"error":{"code":"Failed","message":"Failed"}}`)
+		}))
+
+		// the Sender backs off between retries - use a short delay to keep the test fast
+		accountsClient := storage.NewAccountsClientWithBaseURI(server.URL, "00000000-0000-0000-0000-000000000000")
+		accountsClient.RetryDuration = time.Millisecond
+		client := &ArmClient{
+			storageServiceClient: accountsClient,
+		}
+
+		// the context has no deadline of its own, so only storageAccountKeysTimeout can bound the retries
+		start := time.Now()
+		_, exists, err := client.getKeyForStorageAccount(context.Background(), "mock-resources", tc.AccountName)
+		elapsed := time.Since(start)
+		server.Close()
+
+		if tc.ExpectTimedOut {
+			if elapsed < storageAccountKeysTimeout {
+				t.Fatalf("Expected the retries to continue until the timeout of %s but they stopped after %s", storageAccountKeysTimeout, elapsed)
+			}
+			if elapsed > 10*storageAccountKeysTimeout {
+				t.Fatalf("Expected the retries to stop at the timeout of %s but they took %s", storageAccountKeysTimeout, elapsed)
+			}
+		}
+
+		if tc.ExpectedRequests > 0 && requests != tc.ExpectedRequests {
+			t.Fatalf("Expected %d requests but got %d", tc.ExpectedRequests, requests)
+		}
+
+		// a transient failure mustn't be treated as the Storage Account having been deleted
+		if !exists {
+			t.Fatalf("Expected the Storage Account to be treated as existing")
+		}
+		if tc.ExpectError && err == nil {
+			t.Fatalf("Expected an error but didn't get one")
+		}
+		if !tc.ExpectError && err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+
+		// the key source is only known once the keys have been retrieved
+		expectedKeySource := "key1"
+		if tc.ExpectError {
+			expectedKeySource = ""
+		}
+		if actual := client.storageAccountKeySource("mock-resources", tc.AccountName); actual != expectedKeySource {
+			t.Fatalf("Expected the key source to be %q but got %q", expectedKeySource, actual)
+		}
+	}
+}
+
+func TestArmClientCheckStorageAccountResourceGroup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || !strings.HasSuffix(r.URL.Path, "/providers/Microsoft.Storage/storageAccounts") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"value":[
+			{"id":"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/other-resources/providers/Microsoft.Storage/storageAccounts/mockotheraccount","name":"mockotheraccount"},
+			{"id":"/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/Mock-Resources/providers/Microsoft.Storage/storageAccounts/mockaccount","name":"mockaccount"}
+		]}`)
+	}))
+	defer server.Close()
+
+	client := &ArmClient{
+		storageServiceClient: storage.NewAccountsClientWithBaseURI(server.URL, "00000000-0000-0000-0000-000000000000"),
+	}
+
+	cases := []struct {
+		ResourceGroupName  string
+		StorageAccountName string
+		ExpectError        bool
+	}{
+		{
+			// Resource Group names are case-insensitive
+			ResourceGroupName:  "mock-resources",
+			StorageAccountName: "mockaccount",
+		},
+		{
+			ResourceGroupName:  "mock-resources",
+			StorageAccountName: "mockotheraccount",
+			ExpectError:        true,
+		},
+		{
+			ResourceGroupName:  "mock-resources",
+			StorageAccountName: "mockmissingaccount",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q in %q", tc.StorageAccountName, tc.ResourceGroupName)
+
+		err := client.checkStorageAccountResourceGroup(context.Background(), tc.ResourceGroupName, tc.StorageAccountName)
+		if tc.ExpectError && err == nil {
+			t.Fatalf("Expected an error but didn't get one")
+		}
+		if !tc.ExpectError && err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+	}
+}
+
+func TestArmClientCheckStorageAccountResourceGroup_lookupTimeout(t *testing.T) {
+	cases := []struct {
+		Timeout          time.Duration
+		ExpectedDeadline bool
+	}{
+		{
+			Timeout:          0,
+			ExpectedDeadline: false,
+		},
+		{
+			Timeout:          10 * time.Minute,
+			ExpectedDeadline: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing a timeout of %s", tc.Timeout)
+
+		var deadline time.Time
+		var hasDeadline bool
+		accountsClient := storage.NewAccountsClientWithBaseURI("https://management.example.com", "00000000-0000-0000-0000-000000000000")
+		accountsClient.Sender = autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+			deadline, hasDeadline = r.Context().Deadline()
+			return &http.Response{
+				Request:    r,
+				StatusCode: http.StatusBadRequest,
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}, nil
+		})
+
+		client := &ArmClient{
+			storageServiceClient:        accountsClient,
+			storageAccountLookupTimeout: tc.Timeout,
+		}
+
+		if err := client.checkStorageAccountResourceGroup(context.Background(), "mock-resources", "mockaccount"); err == nil {
+			t.Fatalf("Expected an error but didn't get one")
+		}
+		finished := time.Now()
+
+		if hasDeadline != tc.ExpectedDeadline {
+			t.Fatalf("Expected the request to have a deadline %t but got %t", tc.ExpectedDeadline, hasDeadline)
+		}
+		if hasDeadline && deadline.After(finished.Add(tc.Timeout)) {
+			t.Fatalf("Expected the deadline to be within %s but it was %s", tc.Timeout, deadline.Sub(finished))
+		}
+	}
+}
+
+func TestArmClientGetStorageAccountUsingCache(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Method != http.MethodGet || !strings.HasSuffix(r.URL.Path, "/storageAccounts/mockaccount") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name":"mockaccount","kind":"StorageV2","sku":{"name":"Standard_LRS","tier":"Standard"}}`)
+	}))
+	defer server.Close()
+
+	client := &ArmClient{
+		storageServiceClient: storage.NewAccountsClientWithBaseURI(server.URL, "00000000-0000-0000-0000-000000000000"),
+	}
+
+	// reading many Storage Containers within the same Storage Account concurrently should only retrieve it once
+	var wg sync.WaitGroup
+	errors := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			account, err := client.getStorageAccountUsingCache(context.Background(), "mock-resources", "mockaccount")
+			if err != nil {
+				errors <- err
+				return
+			}
+			if account.Kind != storage.StorageV2 {
+				errors <- fmt.Errorf("Expected the kind %q but got %q", storage.StorageV2, account.Kind)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errors)
+
+	for err := range errors {
+		t.Fatalf("Error retrieving the Storage Account: %+v", err)
+	}
+
+	if requests != 1 {
+		t.Fatalf("Expected the Storage Account to be retrieved once but it was retrieved %d times", requests)
+	}
+}
+
+func TestArmClientGetStorageAccountUsingCache_independentAccounts(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// retrieving the slow Storage Account blocks until the test releases it
+		if strings.HasSuffix(r.URL.Path, "/storageAccounts/slowaccount") {
+			<-release
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"kind":"StorageV2"}`)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := &ArmClient{
+		storageServiceClient: storage.NewAccountsClientWithBaseURI(server.URL, "00000000-0000-0000-0000-000000000000"),
+	}
+
+	go client.getStorageAccountUsingCache(context.Background(), "mock-resources", "slowaccount")
+
+	// wait for the slow lookup to be in progress
+	for {
+		client.storageAccountsRetrievedMu.Lock()
+		_, started := client.storageAccountsRetrieved["mock-resources/slowaccount"]
+		client.storageAccountsRetrievedMu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := client.getStorageAccountUsingCache(context.Background(), "mock-resources", "mockaccount")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Error retrieving the Storage Account: %+v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Expected retrieving a Storage Account not to be blocked by retrieving another Storage Account")
+	}
+}
+
+type testStorageRecordingTransport struct {
+	hosts []string
+}
+
+func (t *testStorageRecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.hosts = append(t.hosts, req.URL.Host)
+	if !strings.Contains(req.URL.Host, "-secondary.") {
+		return nil, fmt.Errorf("mock connection failure")
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusNotFound,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func TestArmClientBlobStorageClientSecondaryEndpoint(t *testing.T) {
+	storageKeyCacheMu.Lock()
+	storageKeyCache["mock-resources/mocksecondary"] = "YWNjZXNza2V5"
+	storageKeyCacheMu.Unlock()
+	defer func() {
+		storageKeyCacheMu.Lock()
+		delete(storageKeyCache, "mock-resources/mocksecondary")
+		storageKeyCacheMu.Unlock()
+	}()
+
+	cases := []struct {
+		Name          string
+		ReadOnly      bool
+		ExpectedHosts []string
+	}{
+		{
+			Name:          "Read-Modify-Write",
+			ReadOnly:      false,
+			ExpectedHosts: []string{"mocksecondary.blob.core.windows.net"},
+		},
+		{
+			Name:          "Read Only",
+			ReadOnly:      true,
+			ExpectedHosts: []string{"mocksecondary.blob.core.windows.net", "mocksecondary-secondary.blob.core.windows.net"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Name)
+
+		transport := &testStorageRecordingTransport{}
+		client := &ArmClient{
+			environment:                       azure.PublicCloud,
+			storageHTTPClient:                 &http.Client{Transport: transport},
+			useSecondaryStorageEndpointOnRead: true,
+		}
+
+		getClient := client.getBlobStorageClientForStorageAccountWithEndpoint
+		if tc.ReadOnly {
+			getClient = client.getReadOnlyBlobStorageClientForStorageAccountWithEndpoint
+		}
+		blobClient, _, err := getClient(context.Background(), "mock-resources", "mocksecondary", "")
+		if err != nil {
+			t.Fatalf("Error building the Blob Storage client: %+v", err)
+		}
+
+		// the result doesn't matter, only where the requests were sent
+		blobClient.GetContainerReference("vhds").Exists()
+
+		if !reflect.DeepEqual(transport.hosts, tc.ExpectedHosts) {
+			t.Fatalf("Expected the requests to be sent to %+v but got %+v", tc.ExpectedHosts, transport.hosts)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package azurerm
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceArmStorageContainer() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceArmStorageContainerRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateArmStorageContainerName,
+			},
+
+			"storage_account_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"resource_group_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"sas_token": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+
+			"container_access_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"metadata": {
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+
+			"properties": {
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceArmStorageContainerRead(d *schema.ResourceData, meta interface{}) error {
+	armClient := meta.(*ArmClient)
+	ctx := armClient.StopContext
+
+	name := d.Get("name").(string)
+	storageAccountName := d.Get("storage_account_name").(string)
+	resourceGroupName := d.Get("resource_group_name").(string)
+	sasToken := d.Get("sas_token").(string)
+
+	var reference *storage.Container
+	if sasToken != "" {
+		// when a SAS Token is specified we don't need (or necessarily have) access to the Storage Account itself
+		if err := validateStorageContainerSASToken(sasToken); err != nil {
+			return err
+		}
+
+		sasUri := url.URL{
+			Scheme:   "https",
+			Host:     fmt.Sprintf("%s.blob.%s", storageAccountName, armClient.environment.StorageEndpointSuffix),
+			Path:     fmt.Sprintf("/%s", name),
+			RawQuery: strings.TrimPrefix(sasToken, "?"),
+		}
+		container, err := storage.GetContainerReferenceFromSASURI(sasUri)
+		if err != nil {
+			return fmt.Errorf("Error building a reference to storage container %q in storage account %q using the SAS Token: %+v", name, storageAccountName, err)
+		}
+		reference = container
+	} else {
+		if resourceGroupName == "" {
+			return fmt.Errorf("`resource_group_name` must be specified when `sas_token` isn't specified")
+		}
+
+		blobClient, accountExists, err := armClient.getReadOnlyBlobStorageClientForStorageAccountWithEndpoint(ctx, resourceGroupName, storageAccountName, "")
+		if err != nil {
+			return err
+		}
+		if !accountExists {
+			return fmt.Errorf("Error: Storage Account %q (Resource Group %q) was not found", storageAccountName, resourceGroupName)
+		}
+		reference = blobClient.GetContainerReference(name)
+	}
+
+	if err := reference.GetProperties(); err != nil {
+		if storageErrorWasStatusCode(err, http.StatusNotFound) {
+			return fmt.Errorf("Error: Storage Container %q (Storage Account %q) was not found", name, storageAccountName)
+		}
+		return fmt.Errorf("Error retrieving properties for storage container %q in storage account %q: %+v", name, storageAccountName, err)
+	}
+
+	if err := reference.GetMetadata(&storage.ContainerMetadataOptions{}); err != nil {
+		return fmt.Errorf("Error retrieving metadata for storage container %q in storage account %q: %+v", name, storageAccountName, err)
+	}
+
+	d.SetId(reference.GetURL())
+
+	accessType := "private"
+	if reference.Properties.PublicAccess != storage.ContainerAccessType("") {
+		accessType = string(reference.Properties.PublicAccess)
+	}
+	d.Set("container_access_type", accessType)
+
+	if err := d.Set("metadata", flattenArmStorageContainerMetadata(reference.Metadata)); err != nil {
+		return fmt.Errorf("Error flattening `metadata`: %+v", err)
+	}
+
+	props := make(map[string]interface{})
+	props["last_modified"] = reference.Properties.LastModified
+	props["lease_status"] = reference.Properties.LeaseStatus
+	props["lease_state"] = reference.Properties.LeaseState
+	props["lease_duration"] = reference.Properties.LeaseDuration
+	d.Set("properties", props)
+
+	return nil
+}
+
+// validateStorageContainerSASToken ensures the SAS Token grants (at least) the Read and List permissions,
+// which are required to retrieve the properties and metadata of the Storage Container
+func validateStorageContainerSASToken(input string) error {
+	values, err := url.ParseQuery(strings.TrimPrefix(input, "?"))
+	if err != nil {
+		return fmt.Errorf("Error parsing the SAS Token: %+v", err)
+	}
+
+	if values.Get("sig") == "" {
+		return fmt.Errorf("The SAS Token is missing a signature (`sig`)")
+	}
+
+	permissions := values.Get("sp")
+	if !strings.Contains(permissions, "r") || !strings.Contains(permissions, "l") {
+		return fmt.Errorf("The SAS Token must grant both the Read (`r`) and List (`l`) permissions but got %q", permissions)
+	}
+
+	return nil
+}
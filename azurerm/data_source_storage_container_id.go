@@ -0,0 +1,60 @@
+package azurerm
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceArmStorageContainerId() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceArmStorageContainerIdRead,
+
+		Schema: map[string]*schema.Schema{
+			"storage_account_name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateArmStorageAccountName,
+			},
+
+			"container_name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateArmStorageContainerName,
+			},
+
+			"url": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"endpoint_suffix": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceArmStorageContainerIdRead(d *schema.ResourceData, meta interface{}) error {
+	armClient := meta.(*ArmClient)
+
+	storageAccountName := d.Get("storage_account_name").(string)
+	containerName := d.Get("container_name").(string)
+	endpointSuffix := armClient.environment.StorageEndpointSuffix
+
+	url := buildStorageContainerURL(storageAccountName, containerName, endpointSuffix)
+
+	d.SetId(url)
+
+	d.Set("url", url)
+	d.Set("endpoint_suffix", endpointSuffix)
+
+	return nil
+}
+
+// buildStorageContainerURL returns the URL of a Storage Container, in the same format as the Storage SDK
+// (and as parsed by parseStorageContainerID) - without making any API calls
+func buildStorageContainerURL(storageAccountName, containerName, endpointSuffix string) string {
+	return fmt.Sprintf("https://%s.blob.%s/%s", storageAccountName, endpointSuffix, containerName)
+}
@@ -0,0 +1,55 @@
+package azurerm
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccDataSourceArmStorageContainerId_basic(t *testing.T) {
+	dataSourceName := "data.azurerm_storage_container_id.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceArmStorageContainerId_basic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "url", "https://example.blob.core.windows.net/vhds"),
+					resource.TestCheckResourceAttr(dataSourceName, "endpoint_suffix", "core.windows.net"),
+				),
+			},
+		},
+	})
+}
+
+func TestBuildStorageContainerURL(t *testing.T) {
+	client, err := storage.NewClient("example", "YWNjZXNza2V5", "core.chinacloudapi.cn", storage.DefaultAPIVersion, true)
+	if err != nil {
+		t.Fatalf("Error building storage client: %+v", err)
+	}
+	blobClient := client.GetBlobService()
+	expected := blobClient.GetContainerReference("vhds").GetURL()
+
+	actual := buildStorageContainerURL("example", "vhds", "core.chinacloudapi.cn")
+	if actual != expected {
+		t.Fatalf("Expected the URL to match the Storage SDK's %q but got %q", expected, actual)
+	}
+
+	id, err := parseStorageContainerID(actual)
+	if err != nil {
+		t.Fatalf("Expected the URL to be parsed but got: %+v", err)
+	}
+	if id.storageAccountName != "example" || id.containerName != "vhds" || id.endpointSuffix != "core.chinacloudapi.cn" {
+		t.Fatalf("Expected the URL to round-trip but got %+v", id)
+	}
+}
+
+const testAccDataSourceArmStorageContainerId_basic = `
+data "azurerm_storage_container_id" "test" {
+  storage_account_name = "example"
+  container_name       = "vhds"
+}
+`
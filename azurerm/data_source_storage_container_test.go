@@ -0,0 +1,187 @@
+package azurerm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccDataSourceArmStorageContainer_basic(t *testing.T) {
+	dataSourceName := "data.azurerm_storage_container.test"
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	config := testAccDataSourceArmStorageContainer_basic(ri, rs, testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "container_access_type", "blob"),
+					resource.TestCheckResourceAttr(dataSourceName, "metadata.%", "1"),
+					resource.TestCheckResourceAttr(dataSourceName, "metadata.hello", "world"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDataSourceArmStorageContainer_sasToken(t *testing.T) {
+	dataSourceName := "data.azurerm_storage_container.test"
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	utcNow := time.Now().UTC()
+	startDate := utcNow.Format(time.RFC3339)
+	endDate := utcNow.Add(time.Hour * 24).Format(time.RFC3339)
+	config := testAccDataSourceArmStorageContainer_sasToken(ri, rs, testLocation(), startDate, endDate)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "container_access_type", "blob"),
+					resource.TestCheckResourceAttr(dataSourceName, "metadata.hello", "world"),
+				),
+			},
+		},
+	})
+}
+
+func TestValidateStorageContainerSASToken(t *testing.T) {
+	cases := []struct {
+		Input string
+		Valid bool
+	}{
+		{
+			Input: "",
+			Valid: false,
+		},
+		{
+			Input: "?sv=2017-07-29&ss=b&srt=sco&sp=rl&se=2028-01-01T00:00:00Z",
+			Valid: false,
+		},
+		{
+			Input: "?sv=2017-07-29&ss=b&srt=sco&sp=r&se=2028-01-01T00:00:00Z&sig=abc123",
+			Valid: false,
+		},
+		{
+			Input: "?sv=2017-07-29&ss=b&srt=sco&sp=wl&se=2028-01-01T00:00:00Z&sig=abc123",
+			Valid: false,
+		},
+		{
+			Input: "?sv=2017-07-29&ss=b&srt=sco&sp=rl&se=2028-01-01T00:00:00Z&sig=abc123",
+			Valid: true,
+		},
+		{
+			Input: "sv=2017-07-29&ss=b&srt=sco&sp=rwdlacup&se=2028-01-01T00:00:00Z&sig=abc123",
+			Valid: true,
+		},
+	}
+
+	for _, v := range cases {
+		t.Logf("[DEBUG] Testing %q", v.Input)
+
+		err := validateStorageContainerSASToken(v.Input)
+		if v.Valid && err != nil {
+			t.Fatalf("Expected the SAS Token to be valid but got: %+v", err)
+		}
+		if !v.Valid && err == nil {
+			t.Fatalf("Expected the SAS Token to be invalid but it wasn't")
+		}
+	}
+}
+
+func testAccDataSourceArmStorageContainer_template(rInt int, rString string, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+  name                     = "acctestacc%s"
+  resource_group_name      = "${azurerm_resource_group.test.name}"
+  location                 = "${azurerm_resource_group.test.location}"
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_container" "test" {
+  name                  = "vhds"
+  resource_group_name   = "${azurerm_resource_group.test.name}"
+  storage_account_name  = "${azurerm_storage_account.test.name}"
+  container_access_type = "blob"
+
+  metadata {
+    hello = "world"
+  }
+}
+`, rInt, location, rString)
+}
+
+func testAccDataSourceArmStorageContainer_basic(rInt int, rString string, location string) string {
+	template := testAccDataSourceArmStorageContainer_template(rInt, rString, location)
+	return fmt.Sprintf(`
+%s
+
+data "azurerm_storage_container" "test" {
+  name                 = "${azurerm_storage_container.test.name}"
+  storage_account_name = "${azurerm_storage_container.test.storage_account_name}"
+  resource_group_name  = "${azurerm_storage_container.test.resource_group_name}"
+}
+`, template)
+}
+
+func testAccDataSourceArmStorageContainer_sasToken(rInt int, rString string, location string, startDate string, endDate string) string {
+	template := testAccDataSourceArmStorageContainer_template(rInt, rString, location)
+	return fmt.Sprintf(`
+%s
+
+data "azurerm_storage_account_sas" "test" {
+  connection_string = "${azurerm_storage_account.test.primary_connection_string}"
+  https_only        = true
+
+  resource_types {
+    service   = true
+    container = true
+    object    = true
+  }
+
+  services {
+    blob  = true
+    queue = false
+    table = false
+    file  = false
+  }
+
+  start  = "%s"
+  expiry = "%s"
+
+  permissions {
+    read    = true
+    write   = false
+    delete  = false
+    list    = true
+    add     = false
+    create  = false
+    update  = false
+    process = false
+  }
+}
+
+data "azurerm_storage_container" "test" {
+  name                 = "${azurerm_storage_container.test.name}"
+  storage_account_name = "${azurerm_storage_container.test.storage_account_name}"
+  sas_token            = "${data.azurerm_storage_account_sas.test.sas}"
+}
+`, template, startDate, endDate)
+}
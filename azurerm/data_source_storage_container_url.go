@@ -0,0 +1,52 @@
+package azurerm
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/validate"
+)
+
+func dataSourceArmStorageContainerUrl() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceArmStorageContainerUrlRead,
+
+		Schema: map[string]*schema.Schema{
+			"url": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validate.URLIsHTTPOrHTTPS,
+			},
+
+			"storage_account_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"container_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"endpoint_suffix": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceArmStorageContainerUrlRead(d *schema.ResourceData, _ interface{}) error {
+	input := d.Get("url").(string)
+
+	id, err := parseStorageContainerID(input)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(input)
+
+	d.Set("storage_account_name", id.storageAccountName)
+	d.Set("container_name", id.containerName)
+	d.Set("endpoint_suffix", id.endpointSuffix)
+
+	return nil
+}
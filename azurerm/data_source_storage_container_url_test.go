@@ -0,0 +1,32 @@
+package azurerm
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccDataSourceArmStorageContainerUrl_basic(t *testing.T) {
+	dataSourceName := "data.azurerm_storage_container_url.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceArmStorageContainerUrl_basic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "storage_account_name", "example"),
+					resource.TestCheckResourceAttr(dataSourceName, "container_name", "vhds"),
+					resource.TestCheckResourceAttr(dataSourceName, "endpoint_suffix", "core.windows.net"),
+				),
+			},
+		},
+	})
+}
+
+const testAccDataSourceArmStorageContainerUrl_basic = `
+data "azurerm_storage_container_url" "test" {
+  url = "https://example.blob.core.windows.net/vhds"
+}
+`
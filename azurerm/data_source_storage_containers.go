@@ -0,0 +1,101 @@
+package azurerm
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceArmStorageContainers() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceArmStorageContainersRead,
+
+		Schema: map[string]*schema.Schema{
+			"storage_account_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"resource_group_name": resourceGroupNameForDataSourceSchema(),
+
+			"prefix": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"names": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func dataSourceArmStorageContainersRead(d *schema.ResourceData, meta interface{}) error {
+	armClient := meta.(*ArmClient)
+	ctx := armClient.StopContext
+
+	storageAccountName := d.Get("storage_account_name").(string)
+	resourceGroupName := d.Get("resource_group_name").(string)
+	prefix := d.Get("prefix").(string)
+
+	blobClient, accountExists, err := armClient.getReadOnlyBlobStorageClientForStorageAccountWithEndpoint(ctx, resourceGroupName, storageAccountName, "")
+	if err != nil {
+		return err
+	}
+	if !accountExists {
+		return fmt.Errorf("Error: Storage Account %q (Resource Group %q) was not found", storageAccountName, resourceGroupName)
+	}
+
+	containers, err := listAllStorageContainers(blobClient, prefix)
+	if err != nil {
+		return fmt.Errorf("Error listing storage containers in storage account %q: %+v", storageAccountName, err)
+	}
+
+	names := make([]interface{}, 0)
+	for _, container := range containers {
+		names = append(names, container.Name)
+	}
+
+	d.SetId(fmt.Sprintf("https://%s.blob.%s/?prefix=%s", storageAccountName, armClient.environment.StorageEndpointSuffix, prefix))
+	if err := d.Set("names", names); err != nil {
+		return fmt.Errorf("Error setting `names`: %+v", err)
+	}
+
+	return nil
+}
+
+type storageContainersLister interface {
+	ListContainers(params storage.ListContainersParameters) (*storage.ContainerListResponse, error)
+}
+
+// listAllStorageContainers returns every Storage Container matching the prefix, following the
+// `NextMarker` returned by the API until all pages have been retrieved
+func listAllStorageContainers(client storageContainersLister, prefix string) ([]storage.Container, error) {
+	containers := make([]storage.Container, 0)
+
+	marker := ""
+	for {
+		resp, err := client.ListContainers(storage.ListContainersParameters{
+			Prefix:  prefix,
+			Marker:  marker,
+			Timeout: 90,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		containers = append(containers, resp.Containers...)
+
+		if resp.NextMarker == "" {
+			break
+		}
+		marker = resp.NextMarker
+	}
+
+	return containers, nil
+}
@@ -0,0 +1,134 @@
+package azurerm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccDataSourceArmStorageContainers_basic(t *testing.T) {
+	dataSourceName := "data.azurerm_storage_containers.test"
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	config := testAccDataSourceArmStorageContainers_basic(ri, rs, testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "names.#", "2"),
+					resource.TestCheckResourceAttr(dataSourceName, "names.0", "logs-one"),
+					resource.TestCheckResourceAttr(dataSourceName, "names.1", "logs-two"),
+				),
+			},
+		},
+	})
+}
+
+type testStorageContainersLister struct {
+	pages    map[string]storage.ContainerListResponse
+	prefixes []string
+}
+
+func (l *testStorageContainersLister) ListContainers(params storage.ListContainersParameters) (*storage.ContainerListResponse, error) {
+	l.prefixes = append(l.prefixes, params.Prefix)
+
+	page, ok := l.pages[params.Marker]
+	if !ok {
+		return nil, fmt.Errorf("unexpected marker %q", params.Marker)
+	}
+
+	return &page, nil
+}
+
+func TestListAllStorageContainers(t *testing.T) {
+	lister := &testStorageContainersLister{
+		pages: map[string]storage.ContainerListResponse{
+			"": {
+				Containers: []storage.Container{{Name: "logs-one"}, {Name: "logs-two"}},
+				NextMarker: "page2",
+			},
+			"page2": {
+				Containers: []storage.Container{{Name: "logs-three"}},
+				NextMarker: "page3",
+			},
+			"page3": {
+				Containers: []storage.Container{},
+			},
+		},
+	}
+
+	containers, err := listAllStorageContainers(lister, "logs-")
+	if err != nil {
+		t.Fatalf("Error listing containers: %+v", err)
+	}
+
+	expected := []string{"logs-one", "logs-two", "logs-three"}
+	if len(containers) != len(expected) {
+		t.Fatalf("Expected %d containers but got %d", len(expected), len(containers))
+	}
+	for i, name := range expected {
+		if containers[i].Name != name {
+			t.Fatalf("Expected %q at index %d but got %q", name, i, containers[i].Name)
+		}
+	}
+
+	if len(lister.prefixes) != 3 {
+		t.Fatalf("Expected 3 pages to be requested but got %d", len(lister.prefixes))
+	}
+	for _, prefix := range lister.prefixes {
+		if prefix != "logs-" {
+			t.Fatalf("Expected every page to be requested with the prefix %q but got %q", "logs-", prefix)
+		}
+	}
+}
+
+func testAccDataSourceArmStorageContainers_basic(rInt int, rString string, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+  name                     = "acctestacc%s"
+  resource_group_name      = "${azurerm_resource_group.test.name}"
+  location                 = "${azurerm_resource_group.test.location}"
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_container" "one" {
+  name                 = "logs-one"
+  resource_group_name  = "${azurerm_resource_group.test.name}"
+  storage_account_name = "${azurerm_storage_account.test.name}"
+}
+
+resource "azurerm_storage_container" "two" {
+  name                 = "logs-two"
+  resource_group_name  = "${azurerm_resource_group.test.name}"
+  storage_account_name = "${azurerm_storage_account.test.name}"
+}
+
+resource "azurerm_storage_container" "other" {
+  name                 = "vhds"
+  resource_group_name  = "${azurerm_resource_group.test.name}"
+  storage_account_name = "${azurerm_storage_account.test.name}"
+}
+
+data "azurerm_storage_containers" "test" {
+  storage_account_name = "${azurerm_storage_account.test.name}"
+  resource_group_name  = "${azurerm_resource_group.test.name}"
+  prefix               = "logs-"
+
+  depends_on = ["azurerm_storage_container.one", "azurerm_storage_container.two", "azurerm_storage_container.other"]
+}
+`, rInt, location, rString)
+}
@@ -7,16 +7,20 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
+	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2017-05-10/resources"
 	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/hashicorp/terraform/helper/mutexkv"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/authentication"
 	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/suppress"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/validate"
 )
 
 // Provider returns a terraform.ResourceProvider.
@@ -75,6 +79,82 @@ func Provider() terraform.ResourceProvider {
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("ARM_MSI_ENDPOINT", ""),
 			},
+
+			"default_storage_container_access_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("ARM_DEFAULT_STORAGE_CONTAINER_ACCESS_TYPE", "private"),
+				ValidateFunc: validateArmStorageContainerAccessType,
+			},
+
+			"allowed_container_access_types": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateArmStorageContainerAccessType,
+				},
+			},
+
+			"container_name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.ValidateRegexp,
+			},
+
+			"storage_api_version": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ARM_STORAGE_API_VERSION", ""),
+				ValidateFunc: validation.StringInSlice([]string{
+					"2015-04-05",
+					"2015-07-08",
+					"2015-12-11",
+					"2016-05-31",
+					"2017-04-17",
+					"2017-07-29",
+				}, false),
+			},
+
+			"storage_resource_manager_endpoint": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("ARM_STORAGE_RESOURCE_MANAGER_ENDPOINT", ""),
+				ValidateFunc: validate.URLIsHTTPOrHTTPS,
+			},
+
+			"storage_http_timeout_seconds": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("ARM_STORAGE_HTTP_TIMEOUT_SECONDS", 0),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
+			"use_secondary_endpoint_on_read": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ARM_USE_SECONDARY_ENDPOINT_ON_READ", false),
+			},
+
+			"storage_account_lookup_timeout_seconds": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("ARM_STORAGE_ACCOUNT_LOOKUP_TIMEOUT_SECONDS", 0),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
+			"storage_strict_read": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("ARM_STORAGE_STRICT_READ", false),
+			},
+
+			"storage_container_create_poll_interval_seconds": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("ARM_STORAGE_CONTAINER_CREATE_POLL_INTERVAL_SECONDS", 2),
+				ValidateFunc: validation.IntAtLeast(1),
+			},
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
@@ -114,6 +194,10 @@ func Provider() terraform.ResourceProvider {
 			"azurerm_snapshot":                              dataSourceArmSnapshot(),
 			"azurerm_storage_account":                       dataSourceArmStorageAccount(),
 			"azurerm_storage_account_sas":                   dataSourceArmStorageAccountSharedAccessSignature(),
+			"azurerm_storage_container":                     dataSourceArmStorageContainer(),
+			"azurerm_storage_container_id":                  dataSourceArmStorageContainerId(),
+			"azurerm_storage_container_url":                 dataSourceArmStorageContainerUrl(),
+			"azurerm_storage_containers":                    dataSourceArmStorageContainers(),
 			"azurerm_subnet":                                dataSourceArmSubnet(),
 			"azurerm_subscription":                          dataSourceArmSubscription(),
 			"azurerm_subscriptions":                         dataSourceArmSubscriptions(),
@@ -247,7 +331,9 @@ func Provider() terraform.ResourceProvider {
 			"azurerm_sql_virtual_network_rule":                resourceArmSqlVirtualNetworkRule(),
 			"azurerm_storage_account":                         resourceArmStorageAccount(),
 			"azurerm_storage_blob":                            resourceArmStorageBlob(),
+			"azurerm_storage_account_blob_service_properties": resourceArmStorageAccountBlobServiceProperties(),
 			"azurerm_storage_container":                       resourceArmStorageContainer(),
+			"azurerm_storage_container_access_policy":         resourceArmStorageContainerAccessPolicy(),
 			"azurerm_storage_share":                           resourceArmStorageShare(),
 			"azurerm_storage_queue":                           resourceArmStorageQueue(),
 			"azurerm_storage_table":                           resourceArmStorageTable(),
@@ -322,6 +408,7 @@ func providerConfigure(p *schema.Provider) schema.ConfigureFunc {
 		}
 
 		client.StopContext = p.StopContext()
+		configureArmClientStorageSettings(client, d)
 
 		// replaces the context between tests
 		p.MetaReset = func() error {
@@ -409,6 +496,29 @@ func determineAzureResourceProvidersToRegister(providerList []resources.Provider
 	return providers
 }
 
+// configureArmClientStorageSettings applies the Storage settings from the Provider block to the client. Each
+// Provider block (including aliases) is configured with its own client, so these apply only to that block.
+func configureArmClientStorageSettings(client *ArmClient, d *schema.ResourceData) {
+	client.defaultStorageContainerAccessType = d.Get("default_storage_container_access_type").(string)
+	for _, v := range d.Get("allowed_container_access_types").([]interface{}) {
+		client.allowedStorageContainerAccessTypes = append(client.allowedStorageContainerAccessTypes, v.(string))
+	}
+	client.storageContainerNameRegex = d.Get("container_name_regex").(string)
+	client.storageAPIVersion = d.Get("storage_api_version").(string)
+	if v := d.Get("storage_resource_manager_endpoint").(string); v != "" {
+		client.overrideStorageResourceManagerEndpoint(v)
+	}
+	if v := d.Get("storage_http_timeout_seconds").(int); v > 0 {
+		client.storageHTTPClient = &http.Client{
+			Timeout: time.Duration(v) * time.Second,
+		}
+	}
+	client.useSecondaryStorageEndpointOnRead = d.Get("use_secondary_endpoint_on_read").(bool)
+	client.storageAccountLookupTimeout = time.Duration(d.Get("storage_account_lookup_timeout_seconds").(int)) * time.Second
+	client.storageStrictRead = d.Get("storage_strict_read").(bool)
+	client.storageContainerCreatePollInterval = time.Duration(d.Get("storage_container_create_poll_interval_seconds").(int)) * time.Second
+}
+
 // registerAzureResourceProvidersWithSubscription uses the providers client to register
 // all Azure resource providers which the Terraform provider may require (regardless of
 // whether they are actually used by the configuration or not). It was confirmed by Microsoft
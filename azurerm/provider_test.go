@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/davecgh/go-spew/spew"
@@ -33,6 +34,44 @@ func TestProvider_impl(t *testing.T) {
 	var _ terraform.ResourceProvider = Provider()
 }
 
+func TestConfigureArmClientStorageSettings_aliases(t *testing.T) {
+	providerSchema := Provider().(*schema.Provider).Schema
+
+	// each Provider block (e.g. one per cloud, or an alias) is configured with its own client
+	public := &ArmClient{}
+	configureArmClientStorageSettings(public, schema.TestResourceDataRaw(t, providerSchema, map[string]interface{}{
+		"default_storage_container_access_type": "blob",
+	}))
+
+	government := &ArmClient{}
+	configureArmClientStorageSettings(government, schema.TestResourceDataRaw(t, providerSchema, map[string]interface{}{
+		"default_storage_container_access_type":          "private",
+		"storage_http_timeout_seconds":                   30,
+		"storage_container_create_poll_interval_seconds": 5,
+	}))
+
+	if actual := public.storageContainerAccessTypeOrDefault(""); actual != "blob" {
+		t.Fatalf("Expected the public client to default to %q but got %q", "blob", actual)
+	}
+	if actual := government.storageContainerAccessTypeOrDefault(""); actual != "private" {
+		t.Fatalf("Expected the government client to default to %q but got %q", "private", actual)
+	}
+
+	if public.storageHTTPClient != nil {
+		t.Fatalf("Expected the public client not to have a HTTP timeout")
+	}
+	if government.storageHTTPClient == nil {
+		t.Fatalf("Expected the government client to have a HTTP timeout")
+	}
+
+	if actual := public.storageContainerCreatePollIntervalOrDefault(); actual != 2*time.Second {
+		t.Fatalf("Expected the public client to poll every %s but got %s", 2*time.Second, actual)
+	}
+	if actual := government.storageContainerCreatePollIntervalOrDefault(); actual != 5*time.Second {
+		t.Fatalf("Expected the government client to poll every %s but got %s", 5*time.Second, actual)
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
 	variables := []string{
 		"ARM_CLIENT_ID",
@@ -1,6 +1,7 @@
 package azurerm
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -740,6 +741,46 @@ func resourceArmStorageAccountDelete(d *schema.ResourceData, meta interface{}) e
 	return nil
 }
 
+// createStorageAccountForStorageContainer provisions a minimal Storage Account, used by Storage Containers
+// which have opted-in to creating their parent Storage Account when it doesn't exist
+func createStorageAccountForStorageContainer(ctx context.Context, client storage.AccountsClient, resourceGroupName, storageAccountName string, input map[string]interface{}) error {
+	location := azureRMNormalizeLocation(input["location"].(string))
+	accountKind := input["account_kind"].(string)
+	storageType := fmt.Sprintf("%s_%s", input["account_tier"].(string), input["account_replication_type"].(string))
+
+	// BlobStorage does not support ZRS
+	if accountKind == string(storage.BlobStorage) && storageType == string(storage.StandardZRS) {
+		return fmt.Errorf("A `account_replication_type` of `ZRS` isn't supported for Blob Storage accounts.")
+	}
+
+	parameters := storage.AccountCreateParameters{
+		Location: &location,
+		Sku: &storage.Sku{
+			Name: storage.SkuName(storageType),
+		},
+		Kind: storage.Kind(accountKind),
+		AccountPropertiesCreateParameters: &storage.AccountPropertiesCreateParameters{
+			EnableHTTPSTrafficOnly: utils.Bool(true),
+		},
+	}
+
+	// AccessTier is only valid for BlobStorage and StorageV2 accounts
+	if accountKind == string(storage.BlobStorage) || accountKind == string(storage.StorageV2) {
+		parameters.AccountPropertiesCreateParameters.AccessTier = storage.AccessTier(blobStorageAccountDefaultAccessTier)
+	}
+
+	future, err := client.Create(ctx, resourceGroupName, storageAccountName, parameters)
+	if err != nil {
+		return fmt.Errorf("Error creating Azure Storage Account %q: %+v", storageAccountName, err)
+	}
+
+	if err := future.WaitForCompletionRef(ctx, client.Client); err != nil {
+		return fmt.Errorf("Error waiting for Azure Storage Account %q to be created: %+v", storageAccountName, err)
+	}
+
+	return nil
+}
+
 func expandStorageAccountCustomDomain(d *schema.ResourceData) *storage.CustomDomain {
 	domains := d.Get("custom_domain").([]interface{})
 	if domains == nil || len(domains) == 0 {
@@ -879,12 +920,27 @@ func validateArmStorageAccountName(v interface{}, k string) (ws []string, es []e
 	input := v.(string)
 
 	if !regexp.MustCompile(`\A([a-z0-9]{3,24})\z`).MatchString(input) {
+		// names are often copied from the Portal, which can display them with uppercase characters
+		if lower := strings.ToLower(input); lower != input && regexp.MustCompile(`\A([a-z0-9]{3,24})\z`).MatchString(lower) {
+			es = append(es, fmt.Errorf("%q can only contain lowercase letters and numbers - did you mean %q?", k, lower))
+			return
+		}
+
 		es = append(es, fmt.Errorf("name can only consist of lowercase letters and numbers, and must be between 3 and 24 characters long"))
 	}
 
 	return
 }
 
+// storageAccountIsGeoRedundant returns whether the SKU replicates the account to a secondary region
+func storageAccountIsGeoRedundant(sku *storage.Sku) bool {
+	if sku == nil {
+		return false
+	}
+
+	return sku.Name == storage.StandardGRS || sku.Name == storage.StandardRAGRS
+}
+
 func validateArmStorageAccountType(v interface{}, k string) (ws []string, es []error) {
 	validAccountTypes := []string{"standard_lrs", "standard_zrs",
 		"standard_grs", "standard_ragrs", "premium_lrs"}
@@ -0,0 +1,235 @@
+package azurerm
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func resourceArmStorageAccountBlobServiceProperties() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceArmStorageAccountBlobServicePropertiesCreateUpdate,
+		Read:   resourceArmStorageAccountBlobServicePropertiesRead,
+		Update: resourceArmStorageAccountBlobServicePropertiesCreateUpdate,
+		Delete: resourceArmStorageAccountBlobServicePropertiesDelete,
+
+		Schema: map[string]*schema.Schema{
+			"storage_account_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"resource_group_name": resourceGroupNameSchema(),
+
+			"cors_rule": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 5,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"allowed_origins": {
+							Type:     schema.TypeList,
+							Required: true,
+							MaxItems: 64,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validation.NoZeroValues,
+							},
+						},
+						"allowed_methods": {
+							Type:     schema.TypeList,
+							Required: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+								ValidateFunc: validation.StringInSlice([]string{
+									"DELETE",
+									"GET",
+									"HEAD",
+									"MERGE",
+									"POST",
+									"OPTIONS",
+									"PUT",
+								}, false),
+							},
+						},
+						"allowed_headers": {
+							Type:     schema.TypeList,
+							Required: true,
+							MaxItems: 64,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"exposed_headers": {
+							Type:     schema.TypeList,
+							Required: true,
+							MaxItems: 64,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"max_age_in_seconds": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntBetween(1, 2000000000),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceArmStorageAccountBlobServicePropertiesCreateUpdate(d *schema.ResourceData, meta interface{}) error {
+	armClient := meta.(*ArmClient)
+	ctx := armClient.StopContext
+
+	resourceGroupName := d.Get("resource_group_name").(string)
+	storageAccountName := d.Get("storage_account_name").(string)
+
+	blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName)
+	if err != nil {
+		return err
+	}
+	if !accountExists {
+		return fmt.Errorf("Storage Account %q Not Found", storageAccountName)
+	}
+
+	// only the CORS rules are sent, so that the Logging & Metrics configuration is left as-is
+	log.Printf("[INFO] Setting the Blob Service Properties for storage account %q.", storageAccountName)
+	properties := storage.ServiceProperties{
+		Cors: &storage.Cors{
+			CorsRule: expandArmStorageAccountBlobServiceCorsRules(d.Get("cors_rule").([]interface{})),
+		},
+	}
+	if err := blobClient.SetServiceProperties(properties); err != nil {
+		return fmt.Errorf("Error setting the Blob Service Properties for storage account %q: %+v", storageAccountName, err)
+	}
+
+	d.SetId(fmt.Sprintf("https://%s.blob.%s/", storageAccountName, armClient.environment.StorageEndpointSuffix))
+	return resourceArmStorageAccountBlobServicePropertiesRead(d, meta)
+}
+
+func resourceArmStorageAccountBlobServicePropertiesRead(d *schema.ResourceData, meta interface{}) error {
+	armClient := meta.(*ArmClient)
+	ctx := armClient.StopContext
+
+	resourceGroupName := d.Get("resource_group_name").(string)
+	storageAccountName := d.Get("storage_account_name").(string)
+
+	blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName)
+	if err != nil {
+		return err
+	}
+	if !accountExists {
+		log.Printf("[DEBUG] Storage account %q not found, removing Blob Service Properties from state", storageAccountName)
+		d.SetId("")
+		return nil
+	}
+
+	properties, err := blobClient.GetServiceProperties()
+	if err != nil {
+		return fmt.Errorf("Error retrieving the Blob Service Properties for storage account %q: %+v", storageAccountName, err)
+	}
+
+	var rules []storage.CorsRule
+	if properties.Cors != nil {
+		rules = properties.Cors.CorsRule
+	}
+	if err := d.Set("cors_rule", flattenArmStorageAccountBlobServiceCorsRules(rules)); err != nil {
+		return fmt.Errorf("Error flattening `cors_rule`: %+v", err)
+	}
+
+	return nil
+}
+
+func resourceArmStorageAccountBlobServicePropertiesDelete(d *schema.ResourceData, meta interface{}) error {
+	armClient := meta.(*ArmClient)
+	ctx := armClient.StopContext
+
+	resourceGroupName := d.Get("resource_group_name").(string)
+	storageAccountName := d.Get("storage_account_name").(string)
+
+	blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName)
+	if err != nil {
+		return err
+	}
+	if !accountExists {
+		log.Printf("[INFO] Storage Account %q doesn't exist so the Blob Service Properties won't exist", storageAccountName)
+		return nil
+	}
+
+	log.Printf("[INFO] Removing the CORS rules from the Blob Service of storage account %q.", storageAccountName)
+	properties := storage.ServiceProperties{
+		Cors: &storage.Cors{
+			CorsRule: []storage.CorsRule{},
+		},
+	}
+	if err := blobClient.SetServiceProperties(properties); err != nil {
+		return fmt.Errorf("Error removing the CORS rules from the Blob Service of storage account %q: %+v", storageAccountName, err)
+	}
+
+	return nil
+}
+
+func expandArmStorageAccountBlobServiceCorsRules(input []interface{}) []storage.CorsRule {
+	rules := make([]storage.CorsRule, 0)
+
+	for _, v := range input {
+		rule := v.(map[string]interface{})
+
+		rules = append(rules, storage.CorsRule{
+			AllowedOrigins:  expandArmStorageAccountBlobServiceCorsList(rule["allowed_origins"].([]interface{})),
+			AllowedMethods:  expandArmStorageAccountBlobServiceCorsList(rule["allowed_methods"].([]interface{})),
+			AllowedHeaders:  expandArmStorageAccountBlobServiceCorsList(rule["allowed_headers"].([]interface{})),
+			ExposedHeaders:  expandArmStorageAccountBlobServiceCorsList(rule["exposed_headers"].([]interface{})),
+			MaxAgeInSeconds: rule["max_age_in_seconds"].(int),
+		})
+	}
+
+	return rules
+}
+
+// the API represents each of these lists as a comma-separated string
+func expandArmStorageAccountBlobServiceCorsList(input []interface{}) string {
+	values := make([]string, 0)
+	for _, v := range input {
+		values = append(values, v.(string))
+	}
+
+	return strings.Join(values, ",")
+}
+
+func flattenArmStorageAccountBlobServiceCorsRules(input []storage.CorsRule) []interface{} {
+	rules := make([]interface{}, 0)
+
+	for _, rule := range input {
+		rules = append(rules, map[string]interface{}{
+			"allowed_origins":    flattenArmStorageAccountBlobServiceCorsList(rule.AllowedOrigins),
+			"allowed_methods":    flattenArmStorageAccountBlobServiceCorsList(rule.AllowedMethods),
+			"allowed_headers":    flattenArmStorageAccountBlobServiceCorsList(rule.AllowedHeaders),
+			"exposed_headers":    flattenArmStorageAccountBlobServiceCorsList(rule.ExposedHeaders),
+			"max_age_in_seconds": rule.MaxAgeInSeconds,
+		})
+	}
+
+	return rules
+}
+
+func flattenArmStorageAccountBlobServiceCorsList(input string) []interface{} {
+	results := make([]interface{}, 0)
+	if input == "" {
+		return results
+	}
+
+	for _, v := range strings.Split(input, ",") {
+		results = append(results, strings.TrimSpace(v))
+	}
+
+	return results
+}
@@ -0,0 +1,192 @@
+package azurerm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccAzureRMStorageAccountBlobServiceProperties_basic(t *testing.T) {
+	resourceName := "azurerm_storage_account_blob_service_properties.test"
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	config := testAccAzureRMStorageAccountBlobServiceProperties_basic(ri, rs, testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageAccountBlobServicePropertiesDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "cors_rule.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "cors_rule.0.allowed_origins.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "cors_rule.0.allowed_methods.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "cors_rule.0.max_age_in_seconds", "3600"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageAccountBlobServiceProperties_update(t *testing.T) {
+	resourceName := "azurerm_storage_account_blob_service_properties.test"
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	location := testLocation()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageAccountBlobServicePropertiesDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMStorageAccountBlobServiceProperties_basic(ri, rs, location),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "cors_rule.#", "1"),
+				),
+			},
+			{
+				Config: testAccAzureRMStorageAccountBlobServiceProperties_multiple(ri, rs, location),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "cors_rule.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "cors_rule.1.allowed_headers.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "cors_rule.1.exposed_headers.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestFlattenArmStorageAccountBlobServiceCorsList(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected []string
+	}{
+		{Input: "", Expected: []string{}},
+		{Input: "GET", Expected: []string{"GET"}},
+		{Input: "GET,PUT", Expected: []string{"GET", "PUT"}},
+		{Input: "x-ms-meta-*, x-ms-version", Expected: []string{"x-ms-meta-*", "x-ms-version"}},
+	}
+
+	for _, v := range cases {
+		t.Logf("[DEBUG] Testing %q", v.Input)
+
+		actual := flattenArmStorageAccountBlobServiceCorsList(v.Input)
+		if len(actual) != len(v.Expected) {
+			t.Fatalf("Expected %d values but got %d", len(v.Expected), len(actual))
+		}
+
+		for i, expected := range v.Expected {
+			if actual[i].(string) != expected {
+				t.Fatalf("Expected %q at index %d but got %q", expected, i, actual[i])
+			}
+		}
+	}
+}
+
+func testCheckAzureRMStorageAccountBlobServicePropertiesDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "azurerm_storage_account_blob_service_properties" {
+			continue
+		}
+
+		storageAccountName := rs.Primary.Attributes["storage_account_name"]
+		resourceGroup := rs.Primary.Attributes["resource_group_name"]
+
+		armClient := testAccProvider.Meta().(*ArmClient)
+		ctx := armClient.StopContext
+		blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroup, storageAccountName)
+		if err != nil {
+			//If we can't get keys then the blob service can't exist
+			return nil
+		}
+		if !accountExists {
+			return nil
+		}
+
+		properties, err := blobClient.GetServiceProperties()
+		if err != nil {
+			return nil
+		}
+
+		if properties.Cors != nil && len(properties.Cors.CorsRule) > 0 {
+			return fmt.Errorf("Bad: Blob Service of Storage Account %q still has CORS rules", storageAccountName)
+		}
+	}
+
+	return nil
+}
+
+func testAccAzureRMStorageAccountBlobServiceProperties_basic(rInt int, rString string, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+  name                     = "acctestacc%s"
+  resource_group_name      = "${azurerm_resource_group.test.name}"
+  location                 = "${azurerm_resource_group.test.location}"
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_account_blob_service_properties" "test" {
+  storage_account_name = "${azurerm_storage_account.test.name}"
+  resource_group_name  = "${azurerm_resource_group.test.name}"
+
+  cors_rule {
+    allowed_origins    = ["https://example.com"]
+    allowed_methods    = ["GET", "HEAD"]
+    allowed_headers    = ["*"]
+    exposed_headers    = ["*"]
+    max_age_in_seconds = 3600
+  }
+}
+`, rInt, location, rString)
+}
+
+func testAccAzureRMStorageAccountBlobServiceProperties_multiple(rInt int, rString string, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+  name                     = "acctestacc%s"
+  resource_group_name      = "${azurerm_resource_group.test.name}"
+  location                 = "${azurerm_resource_group.test.location}"
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_account_blob_service_properties" "test" {
+  storage_account_name = "${azurerm_storage_account.test.name}"
+  resource_group_name  = "${azurerm_resource_group.test.name}"
+
+  cors_rule {
+    allowed_origins    = ["https://example.com"]
+    allowed_methods    = ["GET", "HEAD"]
+    allowed_headers    = ["*"]
+    exposed_headers    = ["*"]
+    max_age_in_seconds = 3600
+  }
+
+  cors_rule {
+    allowed_origins    = ["https://example.org"]
+    allowed_methods    = ["PUT"]
+    allowed_headers    = ["x-ms-meta-*", "x-ms-version"]
+    exposed_headers    = ["x-ms-meta-*"]
+    max_age_in_seconds = 60
+  }
+}
+`, rInt, location, rString)
+}
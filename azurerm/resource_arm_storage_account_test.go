@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2017-10-01/storage"
 	"github.com/hashicorp/terraform/helper/acctest"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
@@ -51,6 +52,38 @@ func TestValidateArmStorageAccountName(t *testing.T) {
 	}
 }
 
+func TestValidateArmStorageAccountName_uppercase(t *testing.T) {
+	_, es := validateArmStorageAccountName("ExampleAccount01", "storage_account_name")
+	if len(es) != 1 {
+		t.Fatalf("Expected a single error but got %d: %+v", len(es), es)
+	}
+
+	expected := `"storage_account_name" can only contain lowercase letters and numbers - did you mean "exampleaccount01"?`
+	if actual := es[0].Error(); actual != expected {
+		t.Fatalf("Expected the error %q but got %q", expected, actual)
+	}
+}
+
+func TestStorageAccountIsGeoRedundant(t *testing.T) {
+	testCases := []struct {
+		sku      *storage.Sku
+		expected bool
+	}{
+		{nil, false},
+		{&storage.Sku{Name: storage.StandardLRS}, false},
+		{&storage.Sku{Name: storage.StandardZRS}, false},
+		{&storage.Sku{Name: storage.PremiumLRS}, false},
+		{&storage.Sku{Name: storage.StandardGRS}, true},
+		{&storage.Sku{Name: storage.StandardRAGRS}, true},
+	}
+
+	for _, test := range testCases {
+		if actual := storageAccountIsGeoRedundant(test.sku); actual != test.expected {
+			t.Fatalf("Expected %+v to be geo-redundant %t but got %t", test.sku, test.expected, actual)
+		}
+	}
+}
+
 func TestAccAzureRMStorageAccount_basic(t *testing.T) {
 	resourceName := "azurerm_storage_account.testsa"
 	ri := acctest.RandInt()
@@ -2,6 +2,7 @@ package azurerm
 
 import (
 	"bytes"
+	"crypto/md5"
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
@@ -9,11 +10,16 @@ import (
 	"log"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/utils"
 )
 
 func resourceArmStorageBlob() *schema.Resource {
@@ -24,6 +30,12 @@ func resourceArmStorageBlob() *schema.Resource {
 		Exists: resourceArmStorageBlobExists,
 		Delete: resourceArmStorageBlobDelete,
 
+		CustomizeDiff: resourceArmStorageBlobCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
@@ -60,17 +72,51 @@ func resourceArmStorageBlob() *schema.Resource {
 				Default:       "application/octet-stream",
 				ConflictsWith: []string{"source_uri"},
 			},
+			"content_encoding": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobHeaderValue,
+			},
+			"cache_control": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobHeaderValue,
+			},
+			"content_disposition": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateArmStorageBlobHeaderValue,
+			},
 			"source": {
 				Type:          schema.TypeString,
 				Optional:      true,
 				ForceNew:      true,
-				ConflictsWith: []string{"source_uri"},
+				ConflictsWith: []string{"source_uri", "source_content"},
 			},
 			"source_uri": {
 				Type:          schema.TypeString,
 				Optional:      true,
 				ForceNew:      true,
-				ConflictsWith: []string{"source"},
+				ConflictsWith: []string{"source", "source_content"},
+			},
+			"source_content": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"source", "source_uri"},
+				ValidateFunc:  validateArmStorageBlobSourceContent,
+			},
+			"content_md5": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"copy_status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"copy_progress": {
+				Type:     schema.TypeString,
+				Computed: true,
 			},
 			"url": {
 				Type:     schema.TypeString,
@@ -90,15 +136,49 @@ func resourceArmStorageBlob() *schema.Resource {
 				ForceNew:     true,
 				ValidateFunc: validateArmStorageBlobAttempts,
 			},
+			"block_size_bytes": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      storageBlobDefaultBlockSize,
+				ValidateFunc: validation.IntBetween(1, storageBlobMaxBlockSize),
+			},
+			"create_snapshot": {
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+			"snapshot_retention_count": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"snapshot_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 		},
 	}
 }
 
+func validateArmStorageBlobName(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if len(value) < 1 || len(value) > 1024 {
+		errors = append(errors, fmt.Errorf("%q must be between 1 and 1024 characters: %q", k, value))
+	}
+
+	if strings.HasSuffix(value, ".") || strings.HasSuffix(value, "/") {
+		errors = append(errors, fmt.Errorf("%q cannot end with a dot or a forward slash: %q", k, value))
+	}
+
+	return
+}
+
 func validateArmStorageBlobParallelism(v interface{}, k string) (ws []string, errors []error) {
 	value := v.(int)
 
 	if value <= 0 {
-		errors = append(errors, fmt.Errorf("Blob Parallelism %q is invalid, must be greater than 0", value))
+		errors = append(errors, fmt.Errorf("Blob Parallelism %d is invalid, must be greater than 0", value))
 	}
 
 	return
@@ -108,22 +188,183 @@ func validateArmStorageBlobAttempts(v interface{}, k string) (ws []string, error
 	value := v.(int)
 
 	if value <= 0 {
-		errors = append(errors, fmt.Errorf("Blob Attempts %q is invalid, must be greater than 0", value))
+		errors = append(errors, fmt.Errorf("Blob Attempts %d is invalid, must be greater than 0", value))
 	}
 
 	return
 }
 
+const (
+	storageBlobDefaultBlockSize = 4 * 1024 * 1024
+
+	// storageBlobMaxBlockSize is the largest block supported from API Version 2016-05-31 onwards
+	storageBlobMaxBlockSize = 100 * 1024 * 1024
+
+	// storageBlobLegacyMaxBlockSize is the largest block supported by API Versions prior to 2016-05-31
+	storageBlobLegacyMaxBlockSize = 4 * 1024 * 1024
+
+	// storageBlobMaxBlockCount is the maximum number of committed blocks in a block blob
+	storageBlobMaxBlockCount = 50000
+)
+
+// storageBlobMaxBlockSizeForAPIVersion returns the largest block which can be uploaded using the given
+// Storage API Version (which are dates, and so can be compared as strings)
+func storageBlobMaxBlockSizeForAPIVersion(apiVersion string) int64 {
+	if apiVersion < "2016-05-31" {
+		return storageBlobLegacyMaxBlockSize
+	}
+
+	return storageBlobMaxBlockSize
+}
+
+// checkStorageBlobBlockCount returns an error if the block size is larger than the API Version supports, or
+// a file of the given size would need more blocks than a block blob can contain when uploaded using it
+func checkStorageBlobBlockCount(fileSize int64, blockSize int64, maxBlockSize int64) error {
+	if blockSize > maxBlockSize {
+		return fmt.Errorf("blocks can be at most %d bytes using the Storage API Version in use - `block_size_bytes` is %d", maxBlockSize, blockSize)
+	}
+
+	blockCount := (fileSize + blockSize - 1) / blockSize
+	if blockCount <= storageBlobMaxBlockCount {
+		return nil
+	}
+
+	minimumBlockSize := (fileSize + storageBlobMaxBlockCount - 1) / storageBlobMaxBlockCount
+	if minimumBlockSize > maxBlockSize {
+		return fmt.Errorf("a file of %d bytes is too large to be uploaded as a block blob, which can contain at most %d blocks of %d bytes", fileSize, storageBlobMaxBlockCount, maxBlockSize)
+	}
+
+	return fmt.Errorf("a file of %d bytes needs %d blocks of %d bytes but a block blob can contain at most %d blocks - `block_size_bytes` must be at least %d", fileSize, blockCount, blockSize, storageBlobMaxBlockCount, minimumBlockSize)
+}
+
 func validateArmStorageBlobSize(v interface{}, k string) (ws []string, errors []error) {
 	value := v.(int)
 
 	if value%512 != 0 {
-		errors = append(errors, fmt.Errorf("Blob Size %q is invalid, must be a multiple of 512", value))
+		errors = append(errors, fmt.Errorf("Blob Size %d is invalid, must be a multiple of 512", value))
+	}
+
+	return
+}
+
+// validateArmStorageBlobHeaderValue ensures the value can be sent as a HTTP header value,
+// which cannot contain control characters (such as newlines) or non-ASCII characters
+func validateArmStorageBlobHeaderValue(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	for _, c := range value {
+		if c == '\t' {
+			continue
+		}
+
+		if c < 0x20 || c > 0x7e {
+			errors = append(errors, fmt.Errorf("%q must only contain printable ASCII characters to be used as a HTTP header value: %q", k, value))
+			return
+		}
+	}
+
+	if strings.TrimSpace(value) != value {
+		errors = append(errors, fmt.Errorf("%q cannot begin or end with whitespace: %q", k, value))
+	}
+
+	return
+}
+
+// storageBlobSourceContentMaxSize is the largest blob which can be uploaded in a single Put Blob request
+// across all of the supported API versions
+const storageBlobSourceContentMaxSize = 64 * 1024 * 1024
+
+func validateArmStorageBlobSourceContent(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if len(value) > storageBlobSourceContentMaxSize {
+		errors = append(errors, fmt.Errorf("%q must be at most %d bytes but is %d bytes - use `source` to upload larger blobs", k, storageBlobSourceContentMaxSize, len(value)))
 	}
 
 	return
 }
 
+// storageBlobContentMD5 returns the base64-encoded MD5 hash of the content, in the format used by the API
+func storageBlobContentMD5(content string) string {
+	hash := md5.Sum([]byte(content))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+func resourceArmStorageBlobCustomizeDiff(diff *schema.ResourceDiff, v interface{}) error {
+	maxBlockSize := int64(storageBlobMaxBlockSize)
+	if armClient, ok := v.(*ArmClient); ok {
+		maxBlockSize = storageBlobMaxBlockSizeForAPIVersion(armClient.storageDataPlaneAPIVersion())
+	}
+	if err := resourceArmStorageBlobCustomizeDiffBlockSize(diff, maxBlockSize); err != nil {
+		return err
+	}
+
+	// changing the snapshot triggers creates a new snapshot
+	if diff.Id() != "" && diff.HasChange("create_snapshot") && len(diff.Get("create_snapshot").(map[string]interface{})) > 0 {
+		if err := diff.SetNewComputed("snapshot_time"); err != nil {
+			return err
+		}
+	}
+
+	return resourceArmStorageBlobCustomizeDiffSourceContent(diff)
+}
+
+// resourceArmStorageBlobCustomizeDiffBlockSize checks the source file can be uploaded using the block size,
+// so that this is surfaced during the plan rather than part way through an upload
+func resourceArmStorageBlobCustomizeDiffBlockSize(diff *schema.ResourceDiff, maxBlockSize int64) error {
+	if diff.Id() != "" || !strings.EqualFold(diff.Get("type").(string), "block") {
+		return nil
+	}
+
+	source := diff.Get("source").(string)
+	if source == "" {
+		return nil
+	}
+
+	blockSize := int64(diff.Get("block_size_bytes").(int))
+	if blockSize > maxBlockSize {
+		return fmt.Errorf("`block_size_bytes` must be at most %d using the Storage API Version in use (API Versions prior to `2016-05-31` only support blocks of up to 4 MiB) but is %d", maxBlockSize, blockSize)
+	}
+
+	// the file may not exist until apply time (for example if it's generated by another resource)
+	info, err := os.Stat(source)
+	if err != nil {
+		log.Printf("[DEBUG] Unable to stat source file %q, skipping the block size check: %s", source, err)
+		return nil
+	}
+
+	if err := checkStorageBlobBlockCount(info.Size(), blockSize, maxBlockSize); err != nil {
+		return fmt.Errorf("Error validating source file %q: %s", source, err)
+	}
+
+	return nil
+}
+
+func resourceArmStorageBlobCustomizeDiffSourceContent(diff *schema.ResourceDiff) error {
+	content, ok := diff.GetOk("source_content")
+	if !ok {
+		return nil
+	}
+
+	if blobType := diff.Get("type").(string); !strings.EqualFold(blobType, "block") {
+		return fmt.Errorf("`source_content` can only be used with a `type` of `block`")
+	}
+
+	// the blob is recreated when its content no longer matches the configured content
+	if diff.Id() != "" {
+		expected := storageBlobContentMD5(content.(string))
+		if actual := diff.Get("content_md5").(string); actual != "" && actual != expected {
+			log.Printf("[DEBUG] The content of the blob has changed (MD5 %q, expected %q)", actual, expected)
+			if err := diff.SetNew("content_md5", expected); err != nil {
+				return err
+			}
+			return diff.ForceNew("content_md5")
+		}
+	}
+
+	return nil
+}
+
 func validateArmStorageBlobType(v interface{}, k string) (ws []string, errors []error) {
 	value := strings.ToLower(v.(string))
 	validTypes := map[string]struct{}{
@@ -163,9 +404,22 @@ func resourceArmStorageBlobCreate(d *schema.ResourceData, meta interface{}) erro
 		options := &storage.CopyOptions{}
 		container := blobClient.GetContainerReference(cont)
 		blob := container.GetBlobReference(name)
-		err := blob.Copy(sourceUri, options)
+		copyID, err := blob.StartCopy(sourceUri, options)
 		if err != nil {
-			return fmt.Errorf("Error creating storage blob on Azure: %s", err)
+			return fmt.Errorf("Error starting copy of %q to storage blob %q: %s", sourceUri, name, err)
+		}
+
+		// the copy happens server-side, so we poll until it's complete rather than uploading the content
+		log.Printf("[DEBUG] Waiting for copy %q of %q to storage blob %q to complete", copyID, sourceUri, name)
+		stateConf := &resource.StateChangeConf{
+			Pending:    []string{storageBlobCopyStatusPending},
+			Target:     []string{storageBlobCopyStatusSuccess},
+			Refresh:    storageBlobCopyStatusRefreshFunc(blob, copyID),
+			Timeout:    d.Timeout(schema.TimeoutCreate),
+			MinTimeout: 5 * time.Second,
+		}
+		if _, err := stateConf.WaitForState(); err != nil {
+			return fmt.Errorf("Error waiting for copy of %q to storage blob %q to complete: %s", sourceUri, name, err)
 		}
 	} else {
 		switch strings.ToLower(blobType) {
@@ -173,6 +427,17 @@ func resourceArmStorageBlobCreate(d *schema.ResourceData, meta interface{}) erro
 			options := &storage.PutBlobOptions{}
 			container := blobClient.GetContainerReference(cont)
 			blob := container.GetBlobReference(name)
+
+			if v, ok := d.GetOk("source_content"); ok {
+				content := v.(string)
+				blob.Properties.ContentType = contentType
+				blob.Properties.ContentMD5 = storageBlobContentMD5(content)
+				if err := blob.CreateBlockBlobFromReader(strings.NewReader(content), options); err != nil {
+					return fmt.Errorf("Error creating storage blob on Azure: %s", err)
+				}
+				break
+			}
+
 			err := blob.CreateBlockBlob(options)
 			if err != nil {
 				return fmt.Errorf("Error creating storage blob on Azure: %s", err)
@@ -182,7 +447,9 @@ func resourceArmStorageBlobCreate(d *schema.ResourceData, meta interface{}) erro
 			if source != "" {
 				parallelism := d.Get("parallelism").(int)
 				attempts := d.Get("attempts").(int)
-				if err := resourceArmStorageBlobBlockUploadFromSource(cont, name, source, contentType, blobClient, parallelism, attempts); err != nil {
+				blockSize := int64(d.Get("block_size_bytes").(int))
+				maxBlockSize := storageBlobMaxBlockSizeForAPIVersion(armClient.storageDataPlaneAPIVersion())
+				if err := resourceArmStorageBlobBlockUploadFromSource(cont, name, source, contentType, blobClient, parallelism, attempts, blockSize, maxBlockSize); err != nil {
 					return fmt.Errorf("Error creating storage blob on Azure: %s", err)
 				}
 			}
@@ -210,10 +477,81 @@ func resourceArmStorageBlobCreate(d *schema.ResourceData, meta interface{}) erro
 		}
 	}
 
+	container := blobClient.GetContainerReference(cont)
+	blob := container.GetBlobReference(name)
+	if err := resourceArmStorageBlobSetProperties(d, blob, sourceUri == ""); err != nil {
+		return fmt.Errorf("Error setting properties of blob %s (container %s, storage account %s): %+v", name, cont, storageAccountName, err)
+	}
+
+	if len(d.Get("create_snapshot").(map[string]interface{})) > 0 {
+		if err := resourceArmStorageBlobCreateSnapshot(d, container, blob); err != nil {
+			return fmt.Errorf("Error creating snapshot of blob %s (container %s, storage account %s): %+v", name, cont, storageAccountName, err)
+		}
+	}
+
 	d.SetId(name)
 	return resourceArmStorageBlobRead(d, meta)
 }
 
+// resourceArmStorageBlobSetProperties retrieves the current properties of the blob and then sets
+// the properties managed by this resource - since any properties not specified will be cleared
+func resourceArmStorageBlobSetProperties(d *schema.ResourceData, blob *storage.Blob, setContentType bool) error {
+	err := blob.GetProperties(&storage.GetBlobPropertiesOptions{})
+	if err != nil {
+		return err
+	}
+
+	if setContentType {
+		blob.Properties.ContentType = d.Get("content_type").(string)
+	}
+	blob.Properties.ContentEncoding = d.Get("content_encoding").(string)
+	blob.Properties.CacheControl = d.Get("cache_control").(string)
+	blob.Properties.ContentDisposition = d.Get("content_disposition").(string)
+
+	return blob.SetProperties(&storage.SetBlobPropertiesOptions{})
+}
+
+const (
+	storageBlobCopyStatusPending = "pending"
+	storageBlobCopyStatusSuccess = "success"
+	storageBlobCopyStatusAborted = "aborted"
+	storageBlobCopyStatusFailed  = "failed"
+)
+
+func storageBlobCopyStatusRefreshFunc(blob *storage.Blob, copyID string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		if err := blob.GetProperties(&storage.GetBlobPropertiesOptions{}); err != nil {
+			return nil, "", fmt.Errorf("Error retrieving the copy status: %s", err)
+		}
+
+		status, err := checkStorageBlobCopyStatus(copyID, blob.Properties)
+		if err != nil {
+			return nil, "", err
+		}
+
+		log.Printf("[DEBUG] Copy %q is %q (progress %q)", copyID, status, blob.Properties.CopyProgress)
+		return blob, status, nil
+	}
+}
+
+// checkStorageBlobCopyStatus returns the status of the copy operation, or an error describing why it failed
+func checkStorageBlobCopyStatus(copyID string, properties storage.BlobProperties) (string, error) {
+	if properties.CopyID != copyID {
+		return "", fmt.Errorf("Expected the copy ID to be %q but got %q - the blob is being copied by another operation", copyID, properties.CopyID)
+	}
+
+	switch properties.CopyStatus {
+	case storageBlobCopyStatusPending, storageBlobCopyStatusSuccess:
+		return properties.CopyStatus, nil
+	case storageBlobCopyStatusAborted:
+		return "", fmt.Errorf("The copy was aborted: %s", properties.CopyStatusDescription)
+	case storageBlobCopyStatusFailed:
+		return "", fmt.Errorf("The copy failed: %s", properties.CopyStatusDescription)
+	}
+
+	return "", fmt.Errorf("Unexpected copy status %q", properties.CopyStatus)
+}
+
 type resourceArmStorageBlobPage struct {
 	offset  int64
 	section *io.SectionReader
@@ -397,7 +735,7 @@ type resourceArmStorageBlobBlock struct {
 	id      string
 }
 
-func resourceArmStorageBlobBlockUploadFromSource(container, name, source, contentType string, client *storage.BlobStorageClient, parallelism, attempts int) error {
+func resourceArmStorageBlobBlockUploadFromSource(container, name, source, contentType string, client *storage.BlobStorageClient, parallelism, attempts int, blockSize, maxBlockSize int64) error {
 	workerCount := parallelism * runtime.NumCPU()
 
 	file, err := os.Open(source)
@@ -406,7 +744,7 @@ func resourceArmStorageBlobBlockUploadFromSource(container, name, source, conten
 	}
 	defer file.Close()
 
-	blockList, parts, err := resourceArmStorageBlobBlockSplit(file)
+	blockList, parts, err := resourceArmStorageBlobBlockSplit(file, blockSize, maxBlockSize)
 	if err != nil {
 		return fmt.Errorf("Error reading and splitting source file for upload %q: %s", source, err)
 	}
@@ -452,11 +790,8 @@ func resourceArmStorageBlobBlockUploadFromSource(container, name, source, conten
 	return nil
 }
 
-func resourceArmStorageBlobBlockSplit(file *os.File) ([]storage.Block, []resourceArmStorageBlobBlock, error) {
-	const (
-		idSize          = 64
-		blockSize int64 = 4 * 1024 * 1024
-	)
+func resourceArmStorageBlobBlockSplit(file *os.File, blockSize, maxBlockSize int64) ([]storage.Block, []resourceArmStorageBlobBlock, error) {
+	const idSize = 64
 	var parts []resourceArmStorageBlobBlock
 	var blockList []storage.Block
 
@@ -465,6 +800,10 @@ func resourceArmStorageBlobBlockSplit(file *os.File) ([]storage.Block, []resourc
 		return nil, nil, fmt.Errorf("Error stating source file %q: %s", file.Name(), err)
 	}
 
+	if err := checkStorageBlobBlockCount(info.Size(), blockSize, maxBlockSize); err != nil {
+		return nil, nil, fmt.Errorf("Error splitting source file %q: %s", file.Name(), err)
+	}
+
 	for i := int64(0); i < info.Size(); i = i + blockSize {
 		entropy := make([]byte, idSize)
 		_, err = rand.Read(entropy)
@@ -535,6 +874,82 @@ func resourceArmStorageBlobBlockUploadWorker(ctx resourceArmStorageBlobBlockUplo
 	}
 }
 
+func resourceArmStorageBlobCreateSnapshot(d *schema.ResourceData, container storageContainerBlobsLister, blob *storage.Blob) error {
+	log.Printf("[INFO] Creating snapshot of storage blob %q", blob.Name)
+	snapshot, err := blob.CreateSnapshot(&storage.SnapshotOptions{})
+	if err != nil {
+		return err
+	}
+	d.Set("snapshot_time", snapshot.UTC().Format(storageBlobSnapshotTimeFormat))
+
+	return pruneArmStorageBlobSnapshots(container, blob.Name, d.Get("snapshot_retention_count").(int))
+}
+
+// storageBlobSnapshotTimeFormat is the format used by the API to identify a snapshot
+const storageBlobSnapshotTimeFormat = "2006-01-02T15:04:05.0000000Z"
+
+// pruneArmStorageBlobSnapshots deletes the oldest snapshots of the blob so that at most retentionCount remain.
+// A retentionCount of 0 retains all of the snapshots.
+func pruneArmStorageBlobSnapshots(container storageContainerBlobsLister, name string, retentionCount int) error {
+	if retentionCount == 0 {
+		return nil
+	}
+
+	snapshots := make([]storage.Blob, 0)
+	params := storage.ListBlobsParameters{
+		Prefix: name,
+		Include: &storage.IncludeBlobDataset{
+			Snapshots: true,
+		},
+	}
+	for {
+		blobs, err := container.ListBlobs(params)
+		if err != nil {
+			return fmt.Errorf("Error listing snapshots: %s", err)
+		}
+
+		for _, b := range blobs.Blobs {
+			// the prefix also matches other blobs whose names begin with this blob's name
+			if b.Name == name && !b.Snapshot.IsZero() {
+				snapshots = append(snapshots, b)
+			}
+		}
+
+		if blobs.NextMarker == "" {
+			break
+		}
+		params.Marker = blobs.NextMarker
+	}
+
+	for _, snapshot := range storageBlobSnapshotsToDelete(snapshots, retentionCount) {
+		log.Printf("[INFO] Deleting snapshot %q of storage blob %q", snapshot.Snapshot.Format(storageBlobSnapshotTimeFormat), name)
+		timestamp := snapshot.Snapshot
+		options := &storage.DeleteBlobOptions{
+			Snapshot: &timestamp,
+		}
+		if _, err := snapshot.DeleteIfExists(options); err != nil {
+			return fmt.Errorf("Error deleting snapshot %q: %s", timestamp.Format(storageBlobSnapshotTimeFormat), err)
+		}
+	}
+
+	return nil
+}
+
+// storageBlobSnapshotsToDelete returns the oldest snapshots beyond the number to retain
+func storageBlobSnapshotsToDelete(snapshots []storage.Blob, retentionCount int) []storage.Blob {
+	if len(snapshots) <= retentionCount {
+		return nil
+	}
+
+	sorted := make([]storage.Blob, len(snapshots))
+	copy(sorted, snapshots)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Snapshot.Before(sorted[j].Snapshot)
+	})
+
+	return sorted[:len(sorted)-retentionCount]
+}
+
 func resourceArmStorageBlobUpdate(d *schema.ResourceData, meta interface{}) error {
 	armClient := meta.(*ArmClient)
 	ctx := armClient.StopContext
@@ -556,16 +971,21 @@ func resourceArmStorageBlobUpdate(d *schema.ResourceData, meta interface{}) erro
 	container := blobClient.GetContainerReference(storageContainerName)
 	blob := container.GetBlobReference(name)
 
-	if d.HasChange("content_type") {
-		blob.Properties.ContentType = d.Get("content_type").(string)
-	}
-
-	options := &storage.SetBlobPropertiesOptions{}
-	err = blob.SetProperties(options)
+	err = resourceArmStorageBlobSetProperties(d, blob, d.Get("source_uri").(string) == "" || d.HasChange("content_type"))
 	if err != nil {
 		return fmt.Errorf("Error setting properties of blob %s (container %s, storage account %s): %+v", name, storageContainerName, storageAccountName, err)
 	}
 
+	if d.HasChange("create_snapshot") && len(d.Get("create_snapshot").(map[string]interface{})) > 0 {
+		if err := resourceArmStorageBlobCreateSnapshot(d, container, blob); err != nil {
+			return fmt.Errorf("Error creating snapshot of blob %s (container %s, storage account %s): %+v", name, storageContainerName, storageAccountName, err)
+		}
+	} else if d.HasChange("snapshot_retention_count") {
+		if err := pruneArmStorageBlobSnapshots(container, name, d.Get("snapshot_retention_count").(int)); err != nil {
+			return fmt.Errorf("Error removing old snapshots of blob %s (container %s, storage account %s): %+v", name, storageContainerName, storageAccountName, err)
+		}
+	}
+
 	return nil
 }
 
@@ -608,6 +1028,12 @@ func resourceArmStorageBlobRead(d *schema.ResourceData, meta interface{}) error
 		return fmt.Errorf("Error getting properties of blob %s (container %s, storage account %s): %+v", name, storageContainerName, storageAccountName, err)
 	}
 	d.Set("content_type", blob.Properties.ContentType)
+	d.Set("content_encoding", blob.Properties.ContentEncoding)
+	d.Set("cache_control", blob.Properties.CacheControl)
+	d.Set("content_disposition", blob.Properties.ContentDisposition)
+	d.Set("content_md5", blob.Properties.ContentMD5)
+	d.Set("copy_status", blob.Properties.CopyStatus)
+	d.Set("copy_progress", blob.Properties.CopyProgress)
 
 	url := blob.GetURL()
 	if url == "" {
@@ -674,7 +1100,11 @@ func resourceArmStorageBlobDelete(d *schema.ResourceData, meta interface{}) erro
 	storageContainerName := d.Get("storage_container_name").(string)
 
 	log.Printf("[INFO] Deleting storage blob %q", name)
-	options := &storage.DeleteBlobOptions{}
+	// a blob can't be deleted whilst it has snapshots, so any snapshots are deleted too - this is the case even when
+	// `create_snapshot` is no longer configured, since snapshots created previously remain
+	options := &storage.DeleteBlobOptions{
+		DeleteSnapshots: utils.Bool(true),
+	}
 	container := blobClient.GetContainerReference(storageContainerName)
 	blob := container.GetBlobReference(name)
 	_, err = blob.DeleteIfExists(options)
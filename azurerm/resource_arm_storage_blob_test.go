@@ -1,17 +1,24 @@
 package azurerm
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"os"
 	"testing"
+	"time"
 
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/hashicorp/terraform/helper/acctest"
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 )
 
@@ -143,6 +150,466 @@ func TestResourceAzureRMStorageBlobAttempts_validation(t *testing.T) {
 	}
 }
 
+func TestResourceAzureRMStorageBlobHeaderValue_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{
+			Value:    "",
+			ErrCount: 0,
+		},
+		{
+			Value:    "gzip",
+			ErrCount: 0,
+		},
+		{
+			Value:    "public, max-age=31536000",
+			ErrCount: 0,
+		},
+		{
+			Value:    "no-cache\r\nX-Injected: true",
+			ErrCount: 1,
+		},
+		{
+			Value:    "max-age=\u00e9",
+			ErrCount: 1,
+		},
+		{
+			Value:    " no-cache",
+			ErrCount: 1,
+		},
+		{
+			Value:    "attachment; filename=\"report.pdf\"",
+			ErrCount: 0,
+		},
+		{
+			Value:    "attachment; filename=report.pdf\n",
+			ErrCount: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobHeaderValue(tc.Value, "azurerm_storage_blob")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected the Azure RM Storage Blob header value %q to trigger %d validation errors but got %d", tc.Value, tc.ErrCount, len(errors))
+		}
+	}
+}
+
+func TestCheckStorageBlobBlockCount(t *testing.T) {
+	cases := []struct {
+		FileSize     int64
+		BlockSize    int64
+		MaxBlockSize int64
+		ExpectError  bool
+	}{
+		{
+			FileSize:     0,
+			BlockSize:    storageBlobDefaultBlockSize,
+			MaxBlockSize: storageBlobMaxBlockSize,
+		},
+		{
+			FileSize:     storageBlobMaxBlockCount * storageBlobDefaultBlockSize,
+			BlockSize:    storageBlobDefaultBlockSize,
+			MaxBlockSize: storageBlobMaxBlockSize,
+		},
+		{
+			FileSize:     storageBlobMaxBlockCount*storageBlobDefaultBlockSize + 1,
+			BlockSize:    storageBlobDefaultBlockSize,
+			MaxBlockSize: storageBlobMaxBlockSize,
+			ExpectError:  true,
+		},
+		{
+			FileSize:     storageBlobMaxBlockCount*storageBlobDefaultBlockSize + 1,
+			BlockSize:    2 * storageBlobDefaultBlockSize,
+			MaxBlockSize: storageBlobMaxBlockSize,
+		},
+		{
+			FileSize:     storageBlobMaxBlockCount*storageBlobMaxBlockSize + 1,
+			BlockSize:    storageBlobMaxBlockSize,
+			MaxBlockSize: storageBlobMaxBlockSize,
+			ExpectError:  true,
+		},
+		{
+			FileSize:     1024,
+			BlockSize:    2 * storageBlobLegacyMaxBlockSize,
+			MaxBlockSize: storageBlobLegacyMaxBlockSize,
+			ExpectError:  true,
+		},
+		{
+			FileSize:     storageBlobMaxBlockCount*storageBlobLegacyMaxBlockSize + 1,
+			BlockSize:    storageBlobLegacyMaxBlockSize,
+			MaxBlockSize: storageBlobLegacyMaxBlockSize,
+			ExpectError:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %d bytes with a block size of %d (at most %d)", tc.FileSize, tc.BlockSize, tc.MaxBlockSize)
+
+		err := checkStorageBlobBlockCount(tc.FileSize, tc.BlockSize, tc.MaxBlockSize)
+		if tc.ExpectError && err == nil {
+			t.Fatalf("Expected an error but didn't get one")
+		}
+		if !tc.ExpectError && err != nil {
+			t.Fatalf("Expected no error but got: %s", err)
+		}
+	}
+}
+
+func TestStorageBlobMaxBlockSizeForAPIVersion(t *testing.T) {
+	cases := []struct {
+		APIVersion string
+		Expected   int64
+	}{
+		{
+			APIVersion: "2015-04-05",
+			Expected:   storageBlobLegacyMaxBlockSize,
+		},
+		{
+			APIVersion: "2015-12-11",
+			Expected:   storageBlobLegacyMaxBlockSize,
+		},
+		{
+			APIVersion: "2016-05-31",
+			Expected:   storageBlobMaxBlockSize,
+		},
+		{
+			APIVersion: storage.DefaultAPIVersion,
+			Expected:   storageBlobMaxBlockSize,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.APIVersion)
+
+		if actual := storageBlobMaxBlockSizeForAPIVersion(tc.APIVersion); actual != tc.Expected {
+			t.Fatalf("Expected the max block size to be %d but got %d", tc.Expected, actual)
+		}
+	}
+}
+
+func TestResourceArmStorageBlobBlockSplit(t *testing.T) {
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("Failed to create local source blob file: %s", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if _, err := file.Write(bytes.Repeat([]byte("a"), 2500)); err != nil {
+		t.Fatalf("Failed to write local source blob file: %s", err)
+	}
+
+	blockList, parts, err := resourceArmStorageBlobBlockSplit(file, 1024, storageBlobMaxBlockSize)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+
+	if len(blockList) != 3 || len(parts) != 3 {
+		t.Fatalf("Expected 3 blocks but got %d blocks and %d parts", len(blockList), len(parts))
+	}
+
+	expectedSizes := []int64{1024, 1024, 452}
+	for i, part := range parts {
+		if size := part.section.Size(); size != expectedSizes[i] {
+			t.Fatalf("Expected block %d to be %d bytes but got %d", i, expectedSizes[i], size)
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobName_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{
+			Value:    "",
+			ErrCount: 1,
+		},
+		{
+			Value:    "example.txt",
+			ErrCount: 0,
+		},
+		{
+			Value:    "path/to/example.txt",
+			ErrCount: 0,
+		},
+		{
+			Value:    "example.",
+			ErrCount: 1,
+		},
+		{
+			Value:    "path/to/",
+			ErrCount: 1,
+		},
+		{
+			Value:    strings.Repeat("a", 1024),
+			ErrCount: 0,
+		},
+		{
+			Value:    strings.Repeat("a", 1025),
+			ErrCount: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobName(tc.Value, "name")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected %d validation errors for %q but got %d", tc.ErrCount, tc.Value, len(errors))
+		}
+	}
+}
+
+func TestResourceAzureRMStorageBlobSourceContent_validation(t *testing.T) {
+	cases := []struct {
+		Value    string
+		ErrCount int
+	}{
+		{
+			Value:    "",
+			ErrCount: 0,
+		},
+		{
+			Value:    "Hello, World!",
+			ErrCount: 0,
+		},
+		{
+			Value:    strings.Repeat("a", storageBlobSourceContentMaxSize),
+			ErrCount: 0,
+		},
+		{
+			Value:    strings.Repeat("a", storageBlobSourceContentMaxSize+1),
+			ErrCount: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageBlobSourceContent(tc.Value, "source_content")
+
+		if len(errors) != tc.ErrCount {
+			t.Fatalf("Expected %d validation errors for content of %d bytes but got %d", tc.ErrCount, len(tc.Value), len(errors))
+		}
+	}
+}
+
+func TestStorageBlobSnapshotsToDelete(t *testing.T) {
+	snapshot := func(minutes int) storage.Blob {
+		return storage.Blob{
+			Name:     "example.vhd",
+			Snapshot: time.Date(2018, 1, 1, 0, minutes, 0, 0, time.UTC),
+		}
+	}
+
+	cases := []struct {
+		Name           string
+		Snapshots      []storage.Blob
+		RetentionCount int
+		Expected       []int
+	}{
+		{
+			Name:           "No Snapshots",
+			Snapshots:      []storage.Blob{},
+			RetentionCount: 2,
+			Expected:       []int{},
+		},
+		{
+			Name:           "Within Retention",
+			Snapshots:      []storage.Blob{snapshot(1), snapshot(2)},
+			RetentionCount: 2,
+			Expected:       []int{},
+		},
+		{
+			Name:           "Oldest Deleted",
+			Snapshots:      []storage.Blob{snapshot(3), snapshot(1), snapshot(4), snapshot(2)},
+			RetentionCount: 2,
+			Expected:       []int{1, 2},
+		},
+		{
+			Name:           "Single Retained",
+			Snapshots:      []storage.Blob{snapshot(2), snapshot(3), snapshot(1)},
+			RetentionCount: 1,
+			Expected:       []int{1, 2},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Name)
+
+		actual := storageBlobSnapshotsToDelete(tc.Snapshots, tc.RetentionCount)
+		if len(actual) != len(tc.Expected) {
+			t.Fatalf("Expected %d snapshots to be deleted but got %d", len(tc.Expected), len(actual))
+		}
+
+		for i, minutes := range tc.Expected {
+			if actual[i].Snapshot.Minute() != minutes {
+				t.Fatalf("Expected snapshot %d to be the one taken at minute %d but got %d", i, minutes, actual[i].Snapshot.Minute())
+			}
+		}
+	}
+}
+
+type testStorageBlobDeleteTransport struct {
+	requests []*http.Request
+}
+
+func (t *testStorageBlobDeleteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req)
+
+	return &http.Response{
+		StatusCode: http.StatusAccepted,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func TestResourceArmStorageBlobDelete_deletesSnapshots(t *testing.T) {
+	storageKeyCacheMu.Lock()
+	storageKeyCache["mock-resources/acctestsnapshots"] = "YWNjZXNza2V5"
+	storageKeyCacheMu.Unlock()
+	defer func() {
+		storageKeyCacheMu.Lock()
+		delete(storageKeyCache, "mock-resources/acctestsnapshots")
+		storageKeyCacheMu.Unlock()
+	}()
+
+	cases := []struct {
+		Name           string
+		CreateSnapshot map[string]interface{}
+	}{
+		{
+			Name: "Snapshots Configured",
+			CreateSnapshot: map[string]interface{}{
+				"enabled": "true",
+			},
+		},
+		{
+			// snapshots created whilst `create_snapshot` was configured remain
+			Name:           "Snapshot Configuration Removed",
+			CreateSnapshot: map[string]interface{}{},
+		},
+	}
+
+	for _, v := range cases {
+		t.Logf("[DEBUG] Testing %q", v.Name)
+
+		transport := &testStorageBlobDeleteTransport{}
+		client := &ArmClient{
+			environment:       azure.PublicCloud,
+			storageHTTPClient: &http.Client{Transport: transport},
+			StopContext:       context.Background(),
+		}
+
+		d := schema.TestResourceDataRaw(t, resourceArmStorageBlob().Schema, map[string]interface{}{
+			"name":                   "example.vhd",
+			"resource_group_name":    "mock-resources",
+			"storage_account_name":   "acctestsnapshots",
+			"storage_container_name": "vhds",
+			"create_snapshot":        v.CreateSnapshot,
+		})
+
+		if err := resourceArmStorageBlobDelete(d, client); err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+
+		if len(transport.requests) != 1 || transport.requests[0].Method != http.MethodDelete {
+			t.Fatalf("Expected a single DELETE request but got %d requests", len(transport.requests))
+		}
+		// the SDK sets the header without canonicalising the name
+		if actual := strings.Join(transport.requests[0].Header["x-ms-delete-snapshots"], ","); actual != "include" {
+			t.Fatalf("Expected the snapshots to be deleted with the blob but `x-ms-delete-snapshots` was %q", actual)
+		}
+	}
+}
+
+func TestStorageBlobContentMD5(t *testing.T) {
+	cases := []struct {
+		Content  string
+		Expected string
+	}{
+		{
+			Content:  "",
+			Expected: "1B2M2Y8AsgTpgAmY7PhCfg==",
+		},
+		{
+			Content:  "Hello, World!",
+			Expected: "ZajifYh5KDgxtmS9i38K1A==",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Content)
+
+		if actual := storageBlobContentMD5(tc.Content); actual != tc.Expected {
+			t.Fatalf("Expected the MD5 to be %q but got %q", tc.Expected, actual)
+		}
+	}
+}
+
+func TestCheckStorageBlobCopyStatus(t *testing.T) {
+	cases := []struct {
+		CopyID         string
+		Properties     storage.BlobProperties
+		ExpectedStatus string
+		ExpectError    bool
+	}{
+		{
+			CopyID:         "abc123",
+			Properties:     storage.BlobProperties{CopyID: "abc123", CopyStatus: "pending"},
+			ExpectedStatus: "pending",
+		},
+		{
+			CopyID:         "abc123",
+			Properties:     storage.BlobProperties{CopyID: "abc123", CopyStatus: "success"},
+			ExpectedStatus: "success",
+		},
+		{
+			CopyID:      "abc123",
+			Properties:  storage.BlobProperties{CopyID: "abc123", CopyStatus: "failed", CopyStatusDescription: "500 InternalError"},
+			ExpectError: true,
+		},
+		{
+			CopyID:      "abc123",
+			Properties:  storage.BlobProperties{CopyID: "abc123", CopyStatus: "aborted"},
+			ExpectError: true,
+		},
+		{
+			CopyID:      "abc123",
+			Properties:  storage.BlobProperties{CopyID: "def456", CopyStatus: "success"},
+			ExpectError: true,
+		},
+		{
+			CopyID:      "abc123",
+			Properties:  storage.BlobProperties{CopyID: "abc123", CopyStatus: "unknown"},
+			ExpectError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q / %q", tc.Properties.CopyID, tc.Properties.CopyStatus)
+
+		status, err := checkStorageBlobCopyStatus(tc.CopyID, tc.Properties)
+		if tc.ExpectError {
+			if err == nil {
+				t.Fatalf("Expected an error but didn't get one")
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %s", err)
+		}
+
+		if status != tc.ExpectedStatus {
+			t.Fatalf("Expected the status to be %q but got %q", tc.ExpectedStatus, status)
+		}
+	}
+}
+
 func TestAccAzureRMStorageBlob_basic(t *testing.T) {
 	ri := acctest.RandInt()
 	rs := strings.ToLower(acctest.RandString(11))
@@ -308,6 +775,8 @@ func TestAccAzureRMStorageBlob_source_uri(t *testing.T) {
 				Config: config,
 				Check: resource.ComposeTestCheckFunc(
 					testCheckAzureRMStorageBlobMatchesFile("azurerm_storage_blob.destination", storage.BlobTypeBlock, sourceBlob.Name()),
+					resource.TestCheckResourceAttr("azurerm_storage_blob.destination", "copy_status", "success"),
+					resource.TestCheckResourceAttrSet("azurerm_storage_blob.destination", "copy_progress"),
 				),
 			},
 		},
@@ -359,6 +828,95 @@ func TestAccAzureRMStorageBlobBlock_blockContentType(t *testing.T) {
 	})
 }
 
+func TestAccAzureRMStorageBlob_contentEncodingAndCacheControl(t *testing.T) {
+	resourceName := "azurerm_storage_blob.test"
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	location := testLocation()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMStorageBlob_contentEncodingAndCacheControl(ri, rs, location, "gzip", "no-cache"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "content_encoding", "gzip"),
+					resource.TestCheckResourceAttr(resourceName, "cache_control", "no-cache"),
+				),
+			},
+			{
+				Config: testAccAzureRMStorageBlob_contentEncodingAndCacheControl(ri, rs, location, "deflate", "public, max-age=3600"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "content_encoding", "deflate"),
+					resource.TestCheckResourceAttr(resourceName, "cache_control", "public, max-age=3600"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlobBlock_sourceContent(t *testing.T) {
+	resourceName := "azurerm_storage_blob.test"
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	location := testLocation()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMStorageBlobBlock_sourceContent(ri, rs, location, "Hello, World!"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "content_md5", "ZajifYh5KDgxtmS9i38K1A=="),
+				),
+			},
+			{
+				Config: testAccAzureRMStorageBlobBlock_sourceContent(ri, rs, location, "Goodbye, World!"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "content_md5", storageBlobContentMD5("Goodbye, World!")),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageBlob_contentDisposition(t *testing.T) {
+	resourceName := "azurerm_storage_blob.test"
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	location := testLocation()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageBlobDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMStorageBlob_contentDisposition(ri, rs, location, "inline"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "content_disposition", "inline"),
+				),
+			},
+			{
+				Config: testAccAzureRMStorageBlob_contentDisposition(ri, rs, location, "attachment; filename=report.pdf"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageBlobExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "content_disposition", "attachment; filename=report.pdf"),
+				),
+			},
+		},
+	})
+}
+
 func testCheckAzureRMStorageBlobExists(name string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 
@@ -753,3 +1311,112 @@ resource "azurerm_storage_blob" "source" {
 }
 `, rInt, location, rString, sourceBlobName, contentType)
 }
+
+func testAccAzureRMStorageBlob_contentEncodingAndCacheControl(rInt int, rString string, location string, contentEncoding string, cacheControl string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+    name = "acctestRG-%d"
+    location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+    name                     = "acctestacc%s"
+    resource_group_name      = "${azurerm_resource_group.test.name}"
+    location                 = "${azurerm_resource_group.test.location}"
+    account_tier             = "Standard"
+    account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "index.html"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "block"
+    content_type = "text/html"
+    content_encoding = "%s"
+    cache_control = "%s"
+}
+`, rInt, location, rString, contentEncoding, cacheControl)
+}
+
+func testAccAzureRMStorageBlob_contentDisposition(rInt int, rString string, location string, contentDisposition string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+    name = "acctestRG-%d"
+    location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+    name                     = "acctestacc%s"
+    resource_group_name      = "${azurerm_resource_group.test.name}"
+    location                 = "${azurerm_resource_group.test.location}"
+    account_tier             = "Standard"
+    account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "vhds"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "report.pdf"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "block"
+    content_type = "application/pdf"
+    content_disposition = "%s"
+}
+`, rInt, location, rString, contentDisposition)
+}
+
+func testAccAzureRMStorageBlobBlock_sourceContent(rInt int, rString string, location string, content string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+    name = "acctestRG-%d"
+    location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+    name                     = "acctestacc%s"
+    resource_group_name      = "${azurerm_resource_group.test.name}"
+    location                 = "${azurerm_resource_group.test.location}"
+    account_tier             = "Standard"
+    account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_container" "test" {
+    name = "source"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    container_access_type = "private"
+}
+
+resource "azurerm_storage_blob" "test" {
+    name = "greeting.txt"
+
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    storage_account_name = "${azurerm_storage_account.test.name}"
+    storage_container_name = "${azurerm_storage_container.test.name}"
+
+    type = "block"
+    content_type = "text/plain"
+    source_content = "%s"
+}
+`, rInt, location, rString, content)
+}
@@ -1,9 +1,22 @@
 package azurerm
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"regexp"
@@ -11,15 +24,28 @@ import (
 	"github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/validate"
 )
 
+// Cost Tags are stored as metadata on the container, since containers don't support tags
+const storageContainerCostTagsMetadataPrefix = "cost_"
+
 func resourceArmStorageContainer() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceArmStorageContainerCreate,
 		Read:   resourceArmStorageContainerRead,
+		Update: resourceArmStorageContainerUpdate,
 		Exists: resourceArmStorageContainerExists,
 		Delete: resourceArmStorageContainerDelete,
 
+		CustomizeDiff: resourceArmStorageContainerCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Delete: schema.DefaultTimeout(30 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:         schema.TypeString,
@@ -29,29 +55,219 @@ func resourceArmStorageContainer() *schema.Resource {
 			},
 			"resource_group_name": resourceGroupNameSchema(),
 			"storage_account_name": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateArmStorageAccountName,
 			},
 			"container_access_type": {
 				Type:         schema.TypeString,
 				Optional:     true,
-				ForceNew:     true,
-				Default:      "private",
+				Computed:     true,
 				ValidateFunc: validateArmStorageContainerAccessType,
 			},
+			"metadata": {
+				Type:         schema.TypeMap,
+				Optional:     true,
+				ValidateFunc: validateArmStorageContainerUserMetadata,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"cost_tags": {
+				Type:         schema.TypeMap,
+				Optional:     true,
+				ValidateFunc: validateArmStorageContainerMetadata,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"allow_account_move": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"ignore_metadata_changes": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"require_empty_on_adopt": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"wait_for_deletion": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"check_anonymous_access": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"anonymous_access_effective": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"custom_blob_endpoint": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validate.URLIsHTTPOrHTTPS,
+			},
+			"create_account_if_missing": {
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"location": locationSchema(),
+						"account_tier": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+							ValidateFunc: validation.StringInSlice([]string{
+								"Standard",
+								"Premium",
+							}, true),
+							DiffSuppressFunc: ignoreCaseDiffSuppressFunc,
+						},
+						"account_replication_type": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+							ValidateFunc: validation.StringInSlice([]string{
+								"LRS",
+								"ZRS",
+								"GRS",
+								"RAGRS",
+							}, true),
+							DiffSuppressFunc: ignoreCaseDiffSuppressFunc,
+						},
+						"account_kind": {
+							Type:     schema.TypeString,
+							Optional: true,
+							ForceNew: true,
+							Default:  "StorageV2",
+							ValidateFunc: validation.StringInSlice([]string{
+								"Storage",
+								"BlobStorage",
+								"StorageV2",
+							}, true),
+						},
+					},
+				},
+			},
+			"last_operation": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"account_created": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"account_kind": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"account_tier": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"access_key_source": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"primary_blob_endpoint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"secondary_blob_endpoint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"properties": {
 				Type:     schema.TypeMap,
 				Computed: true,
 			},
+			"lease_status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"lease_state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"lease_duration": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"lease_active": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+			"initial_blob": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateArmStorageBlobName,
+						},
+						"source": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"source_content": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateArmStorageBlobSourceContent,
+						},
+						"content_type": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "application/octet-stream",
+						},
+					},
+				},
+			},
+			"cors_rule": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Removed:  "CORS rules apply to the Blob Service of the Storage Account rather than individual Containers - please use the `azurerm_storage_account_blob_service_properties` resource instead",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{},
+				},
+			},
 		},
 	}
 }
 
-//Following the naming convention as laid out in the docs
+// storageContainerSystemNames are the names of the containers reserved by Azure, which don't follow the
+// naming rules for other containers
+var storageContainerSystemNames = map[string]struct{}{
+	"$root":           {},
+	"$web":            {},
+	"$logs":           {},
+	"$blobchangefeed": {},
+}
+
+// Following the naming convention as laid out in the docs
 func validateArmStorageContainerName(v interface{}, k string) (ws []string, errors []error) {
 	value := v.(string)
-	if !regexp.MustCompile(`^\$root$|^[0-9a-z-]+$`).MatchString(value) {
+	if _, ok := storageContainerSystemNames[value]; ok {
+		return
+	}
+
+	if !regexp.MustCompile(`^[0-9a-z-]+$`).MatchString(value) {
 		errors = append(errors, fmt.Errorf(
 			"only lowercase alphanumeric characters and hyphens allowed in %q: %q",
 			k, value))
@@ -64,6 +280,37 @@ func validateArmStorageContainerName(v interface{}, k string) (ws []string, erro
 		errors = append(errors, fmt.Errorf(
 			"%q cannot begin with a hyphen: %q", k, value))
 	}
+	if strings.Contains(value, "--") {
+		errors = append(errors, fmt.Errorf(
+			"%q cannot contain consecutive hyphens: %q", k, value))
+	}
+	return
+}
+
+// Metadata keys must be valid C# identifiers, which the service returns in lower case
+func validateArmStorageContainerMetadata(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(map[string]interface{})
+
+	for key := range value {
+		if !regexp.MustCompile(`^[a-z_][a-z0-9_]*$`).MatchString(key) {
+			errors = append(errors, fmt.Errorf(
+				"%q keys must start with a lowercase letter or underscore and only contain lowercase alphanumeric characters and underscores: %q", k, key))
+		}
+	}
+
+	return
+}
+
+// Cost Tags are stored as metadata with a prefix, so the prefix can't be used by the rest of the metadata
+func validateArmStorageContainerUserMetadata(v interface{}, k string) (ws []string, errors []error) {
+	ws, errors = validateArmStorageContainerMetadata(v, k)
+
+	for key := range v.(map[string]interface{}) {
+		if strings.HasPrefix(key, storageContainerCostTagsMetadataPrefix) {
+			errors = append(errors, fmt.Errorf("%q keys starting with %q are reserved for `cost_tags`: %q", k, storageContainerCostTagsMetadataPrefix, key))
+		}
+	}
+
 	return
 }
 
@@ -81,6 +328,64 @@ func validateArmStorageContainerAccessType(v interface{}, k string) (ws []string
 	return
 }
 
+func resourceArmStorageContainerCustomizeDiff(diff *schema.ResourceDiff, v interface{}) error {
+	// moving a container between Storage Accounts recreates it - the blobs within it aren't moved
+	if diff.Id() != "" && diff.HasChange("storage_account_name") {
+		old, new := diff.GetChange("storage_account_name")
+		if !diff.Get("allow_account_move").(bool) {
+			return fmt.Errorf("Changing the `storage_account_name` of Storage Container %q from %q to %q would destroy the Container "+
+				"(including all of the blobs within it) and create an empty Container in the new Storage Account. To confirm this "+
+				"set `allow_account_move` to `true`.", diff.Get("name").(string), old.(string), new.(string))
+		}
+
+		log.Printf("[WARN] Storage Container %q is moving from Storage Account %q to %q: the Container will be "+
+			"destroyed (including all of the blobs within it) and an empty Container will be created in the new Storage Account. "+
+			"Data isn't migrated between Storage Accounts.", diff.Get("name").(string), old.(string), new.(string))
+	}
+
+	// changing the triggers re-reads the container, so the lease state may change
+	if diff.Id() != "" && diff.HasChange("triggers") {
+		for _, key := range []string{"properties", "lease_status", "lease_state", "lease_duration", "lease_active"} {
+			if err := diff.SetNewComputed(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	if diff.Id() != "" && (diff.HasChange("check_anonymous_access") || diff.HasChange("container_access_type")) {
+		if err := diff.SetNewComputed("anonymous_access_effective"); err != nil {
+			return err
+		}
+	}
+
+	if armClient, ok := v.(*ArmClient); ok && (diff.Id() == "" || diff.HasChange("container_access_type")) {
+		accessType := armClient.storageContainerAccessTypeOrDefault(diff.Get("container_access_type").(string))
+		if err := armClient.checkStorageContainerAccessTypeIsAllowed(accessType); err != nil {
+			return fmt.Errorf("Error validating Storage Container %q: %s", diff.Get("name").(string), err)
+		}
+	}
+
+	if armClient, ok := v.(*ArmClient); ok && diff.Id() == "" {
+		if err := armClient.checkStorageContainerNameMatchesPolicy(diff.Get("name").(string)); err != nil {
+			return fmt.Errorf("Error validating Storage Container %q: %s", diff.Get("name").(string), err)
+		}
+	}
+
+	// the operation performed is only known once the update has been applied
+	if diff.Id() != "" {
+		for _, key := range []string{"container_access_type", "metadata", "cost_tags", "ignore_metadata_changes", "triggers"} {
+			if diff.HasChange(key) {
+				if err := diff.SetNewComputed("last_operation"); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
 func resourceArmStorageContainerCreate(d *schema.ResourceData, meta interface{}) error {
 	armClient := meta.(*ArmClient)
 	ctx := armClient.StopContext
@@ -88,168 +393,1199 @@ func resourceArmStorageContainerCreate(d *schema.ResourceData, meta interface{})
 	resourceGroupName := d.Get("resource_group_name").(string)
 	storageAccountName := d.Get("storage_account_name").(string)
 
-	blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName)
+	blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccountWithEndpoint(ctx, resourceGroupName, storageAccountName, d.Get("custom_blob_endpoint").(string))
 	if err != nil {
 		return err
 	}
+	accountCreated := false
 	if !accountExists {
-		return fmt.Errorf("Storage Account %q Not Found", storageAccountName)
+		if err := armClient.checkStorageAccountResourceGroup(ctx, resourceGroupName, storageAccountName); err != nil {
+			return err
+		}
+
+		v, ok := d.GetOk("create_account_if_missing")
+		if !ok {
+			return fmt.Errorf("Storage Account %q Not Found", storageAccountName)
+		}
+
+		log.Printf("[INFO] Storage Account %q (Resource Group %q) doesn't exist - creating it.", storageAccountName, resourceGroupName)
+		input := v.([]interface{})[0].(map[string]interface{})
+		if err := createStorageAccountForStorageContainer(ctx, armClient.storageServiceClient, resourceGroupName, storageAccountName, input); err != nil {
+			return err
+		}
+		accountCreated = true
+
+		blobClient, accountExists, err = armClient.getBlobStorageClientForStorageAccountWithEndpoint(ctx, resourceGroupName, storageAccountName, d.Get("custom_blob_endpoint").(string))
+		if err != nil {
+			return err
+		}
+		if !accountExists {
+			return fmt.Errorf("Storage Account %q Not Found after creation", storageAccountName)
+		}
 	}
+	d.Set("account_created", accountCreated)
 
 	name := d.Get("name").(string)
 
-	var accessType storage.ContainerAccessType
-	if d.Get("container_access_type").(string) == "private" {
-		accessType = storage.ContainerAccessType("")
-	} else {
-		accessType = storage.ContainerAccessType(d.Get("container_access_type").(string))
+	initialBlobs, err := expandArmStorageContainerInitialBlobs(d.Get("initial_blob").([]interface{}))
+	if err != nil {
+		return fmt.Errorf("Error expanding `initial_blob` for container %q in storage account %q: %s", name, storageAccountName, err)
+	}
+
+	if err := armClient.claimStorageContainer(storageAccountName, name); err != nil {
+		return err
 	}
+	// the claim is released if the container isn't saved to the state, so that retrying doesn't report a conflict
+	defer func() {
+		if d.Id() == "" {
+			armClient.releaseStorageContainer(storageAccountName, name)
+		}
+	}()
+
+	accessTypeRaw := armClient.storageContainerAccessTypeOrDefault(d.Get("container_access_type").(string))
+	accessType := expandArmStorageContainerAccessType(accessTypeRaw)
 
 	log.Printf("[INFO] Creating container %q in storage account %q.", name, storageAccountName)
 	reference := blobClient.GetContainerReference(name)
 
-	err = resource.Retry(120*time.Second, checkContainerIsCreated(reference))
+	var created bool
+	err = retryStorageContainerCreate(d.Timeout(schema.TimeoutCreate), armClient.storageContainerCreatePollIntervalOrDefault(), checkContainerIsCreated(reference, &created))
 	if err != nil {
-		return fmt.Errorf("Error creating container %q in storage account %q: %s", name, storageAccountName, err)
+		return fmt.Errorf("Error creating container %q in storage account %q: %s", name, storageAccountName, describeStorageError(err))
+	}
+
+	if !created && d.Get("require_empty_on_adopt").(bool) {
+		if err := checkStorageContainerIsEmpty(reference); err != nil {
+			return fmt.Errorf("Error adopting existing container %q in storage account %q: %s", name, storageAccountName, err)
+		}
 	}
 
 	permissions := storage.ContainerPermissions{
 		AccessType: accessType,
 	}
-	permissionOptions := &storage.SetContainerPermissionOptions{}
-	err = reference.SetPermissions(permissions, permissionOptions)
-	if err != nil {
-		return fmt.Errorf("Error setting permissions for container %s in storage account %s: %+v", name, storageAccountName, err)
+	if err := setStorageContainerPermissionsAllowingConcurrentCreation(reference, permissions); err != nil {
+		return fmt.Errorf("Error setting permissions for container %s in storage account %s: %s", name, storageAccountName, describeStorageError(err))
+	}
+
+	metadata := d.Get("metadata").(map[string]interface{})
+	costTags := d.Get("cost_tags").(map[string]interface{})
+	if len(metadata) > 0 || len(costTags) > 0 {
+		reference.Metadata = expandArmStorageContainerMetadataWithCostTags(expandArmStorageContainerMetadata(metadata), costTags)
+		if err := reference.SetMetadata(&storage.ContainerMetadataOptions{}); err != nil {
+			return fmt.Errorf("Error setting metadata for container %q in storage account %q: %s", name, storageAccountName, describeStorageError(err))
+		}
+	}
+
+	// the initial blobs are only uploaded into newly created containers, to avoid overwriting existing blobs
+	if created {
+		for _, blob := range initialBlobs {
+			log.Printf("[INFO] Uploading initial blob %q into container %q in storage account %q.", blob.name, name, storageAccountName)
+			if err := uploadArmStorageContainerInitialBlob(blobClient, name, blob, storageBlobMaxBlockSizeForAPIVersion(armClient.storageDataPlaneAPIVersion())); err != nil {
+				return fmt.Errorf("Error uploading initial blob %q into container %q in storage account %q: %s", blob.name, name, storageAccountName, describeStorageError(err))
+			}
+		}
 	}
 
 	d.SetId(name)
+
+	// an existing container with this name is adopted rather than created
+	if created {
+		d.Set("last_operation", "created")
+	} else {
+		d.Set("last_operation", "adopted")
+	}
+
 	return resourceArmStorageContainerRead(d, meta)
 }
 
-func checkContainerIsCreated(reference *storage.Container) func() *resource.RetryError {
-	return func() *resource.RetryError {
-		createOptions := &storage.CreateContainerOptions{}
-		_, err := reference.CreateIfNotExists(createOptions)
-		if err != nil {
-			return resource.RetryableError(err)
+type storageContainerInitialBlob struct {
+	name          string
+	source        string
+	sourceContent string
+	contentType   string
+}
+
+// expandArmStorageContainerInitialBlobs validates the `initial_blob` blocks, which can't use ConflictsWith
+// since they're nested within a list
+func expandArmStorageContainerInitialBlobs(input []interface{}) ([]storageContainerInitialBlob, error) {
+	blobs := make([]storageContainerInitialBlob, 0, len(input))
+	names := make(map[string]struct{}, len(input))
+
+	for _, raw := range input {
+		v := raw.(map[string]interface{})
+		blob := storageContainerInitialBlob{
+			name:          v["name"].(string),
+			source:        v["source"].(string),
+			sourceContent: v["source_content"].(string),
+			contentType:   v["content_type"].(string),
+		}
+
+		if _, exists := names[blob.name]; exists {
+			return nil, fmt.Errorf("the blob %q is specified more than once", blob.name)
+		}
+		names[blob.name] = struct{}{}
+
+		if (blob.source == "") == (blob.sourceContent == "") {
+			return nil, fmt.Errorf("exactly one of `source` or `source_content` must be specified for the blob %q", blob.name)
 		}
 
+		blobs = append(blobs, blob)
+	}
+
+	return blobs, nil
+}
+
+func uploadArmStorageContainerInitialBlob(client *storage.BlobStorageClient, containerName string, blob storageContainerInitialBlob, maxBlockSize int64) error {
+	if blob.source != "" {
+		return resourceArmStorageBlobBlockUploadFromSource(containerName, blob.name, blob.source, blob.contentType, client, 8, 1, storageBlobDefaultBlockSize, maxBlockSize)
+	}
+
+	reference := client.GetContainerReference(containerName).GetBlobReference(blob.name)
+	reference.Properties.ContentType = blob.contentType
+	reference.Properties.ContentMD5 = storageBlobContentMD5(blob.sourceContent)
+	return reference.CreateBlockBlobFromReader(strings.NewReader(blob.sourceContent), &storage.PutBlobOptions{})
+}
+
+type storageContainerPermissionsClient interface {
+	GetPermissions(options *storage.GetContainerPermissionOptions) (*storage.ContainerPermissions, error)
+	SetPermissions(permissions storage.ContainerPermissions, options *storage.SetContainerPermissionOptions) error
+}
+
+// setStorageContainerPermissionsAllowingConcurrentCreation sets the permissions on a newly created container.
+// When the same container is being created concurrently (e.g. by another workspace) the service can return
+// a Conflict - which is treated as success providing the container has ended up with the same access type.
+func setStorageContainerPermissionsAllowingConcurrentCreation(client storageContainerPermissionsClient, permissions storage.ContainerPermissions) error {
+	err := client.SetPermissions(permissions, &storage.SetContainerPermissionOptions{})
+	if err == nil {
 		return nil
 	}
+
+	if !storageErrorWasStatusCode(err, http.StatusConflict) {
+		return err
+	}
+
+	existing, getErr := client.GetPermissions(&storage.GetContainerPermissionOptions{})
+	if getErr != nil {
+		return fmt.Errorf("%+v (retrieving the existing permissions after a conflict: %+v)", err, getErr)
+	}
+
+	if existing.AccessType != permissions.AccessType {
+		return err
+	}
+
+	log.Printf("[DEBUG] Setting the permissions conflicted with a concurrent operation, but the access type matches - continuing")
+	return nil
 }
 
-// resourceAzureStorageContainerRead does all the necessary API calls to
-// read the status of the storage container off Azure.
-func resourceArmStorageContainerRead(d *schema.ResourceData, meta interface{}) error {
+func resourceArmStorageContainerUpdate(d *schema.ResourceData, meta interface{}) error {
 	armClient := meta.(*ArmClient)
 	ctx := armClient.StopContext
 
 	resourceGroupName := d.Get("resource_group_name").(string)
 	storageAccountName := d.Get("storage_account_name").(string)
 
-	blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName)
+	storageClient, accountExists, err := armClient.getStorageClientForStorageAccountWithEndpoint(ctx, resourceGroupName, storageAccountName, d.Get("custom_blob_endpoint").(string))
 	if err != nil {
 		return err
 	}
 	if !accountExists {
-		log.Printf("[DEBUG] Storage account %q not found, removing container %q from state", storageAccountName, d.Id())
-		d.SetId("")
-		return nil
+		return fmt.Errorf("Storage Account %q Not Found", storageAccountName)
 	}
 
 	name := d.Get("name").(string)
-	containers, err := blobClient.ListContainers(storage.ListContainersParameters{
-		Prefix:  name,
-		Timeout: 90,
-	})
-	if err != nil {
-		return fmt.Errorf("Failed to retrieve storage containers in account %q: %s", name, err)
+	blobClient := storageClient.GetBlobService()
+	reference := blobClient.GetContainerReference(name)
+
+	operation := "noop"
+
+	if d.HasChange("container_access_type") {
+		operation = "updated"
+		accessTypeRaw := armClient.storageContainerAccessTypeOrDefault(d.Get("container_access_type").(string))
+		log.Printf("[INFO] Updating access type for container %q in storage account %q to %q.", name, storageAccountName, accessTypeRaw)
+
+		key, _, err := armClient.getKeyForStorageAccount(ctx, resourceGroupName, storageAccountName)
+		if err != nil {
+			return err
+		}
+
+		aclClient, err := newStorageContainerACLClient(storageClient, storageAccountName, key, armClient.storageDataPlaneAPIVersion(), reference.GetURL())
+		if err != nil {
+			return fmt.Errorf("Error building the ACL client for container %q in storage account %q: %+v", name, storageAccountName, err)
+		}
+
+		if err := aclClient.setAccessTypePreservingPolicies(expandArmStorageContainerAccessType(accessTypeRaw)); err != nil {
+			return fmt.Errorf("Error updating permissions for container %q in storage account %q: %s", name, storageAccountName, describeStorageError(err))
+		}
 	}
 
-	var found bool
-	for _, cont := range containers.Containers {
-		if cont.Name == name {
-			found = true
+	if d.HasChange("metadata") || d.HasChange("cost_tags") {
+		operation = "updated"
+		log.Printf("[INFO] Updating metadata for container %q in storage account %q.", name, storageAccountName)
 
-			props := make(map[string]interface{})
-			props["last_modified"] = cont.Properties.LastModified
-			props["lease_status"] = cont.Properties.LeaseStatus
-			props["lease_state"] = cont.Properties.LeaseState
-			props["lease_duration"] = cont.Properties.LeaseDuration
+		metadata := expandArmStorageContainerMetadata(d.Get("metadata").(map[string]interface{}))
+		if d.Get("ignore_metadata_changes").(bool) {
+			// the metadata is also managed outside of Terraform, so the configured keys are applied over the existing metadata
+			if err := reference.GetMetadata(&storage.ContainerMetadataOptions{}); err != nil {
+				return fmt.Errorf("Error retrieving metadata for container %q in storage account %q: %+v", name, storageAccountName, err)
+			}
+			o, n := d.GetChange("metadata")
+			metadata = mergeArmStorageContainerMetadata(reference.Metadata, o.(map[string]interface{}), n.(map[string]interface{}))
+		}
 
-			d.Set("properties", props)
+		reference.Metadata = expandArmStorageContainerMetadataWithCostTags(metadata, d.Get("cost_tags").(map[string]interface{}))
+		if err := reference.SetMetadata(&storage.ContainerMetadataOptions{}); err != nil {
+			return fmt.Errorf("Error updating metadata for container %q in storage account %q: %s", name, storageAccountName, describeStorageError(err))
 		}
 	}
 
-	if !found {
-		log.Printf("[INFO] Storage container %q does not exist in account %q, removing from state...", name, storageAccountName)
-		d.SetId("")
+	d.Set("last_operation", operation)
+
+	return resourceArmStorageContainerRead(d, meta)
+}
+
+// storageContainerACLClient retrieves and sets the Access Control List of a container without parsing the stored
+// access policies. The SDK only models the Read, Write & Delete permissions - so round-tripping the policies through
+// it would silently drop any Add, Create or List permissions granted outside of Terraform when the access type changes.
+type storageContainerACLClient struct {
+	client       *storage.Client
+	accountName  string
+	accountKey   []byte
+	apiVersion   string
+	containerURL string
+}
+
+func newStorageContainerACLClient(client *storage.Client, accountName, accountKey, apiVersion, containerURL string) (*storageContainerACLClient, error) {
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding the Access Key for storage account %q: %+v", accountName, err)
 	}
 
-	return nil
+	return &storageContainerACLClient{
+		client:       client,
+		accountName:  accountName,
+		accountKey:   key,
+		apiVersion:   apiVersion,
+		containerURL: containerURL,
+	}, nil
 }
 
-func resourceArmStorageContainerExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	armClient := meta.(*ArmClient)
-	ctx := armClient.StopContext
+// setAccessTypePreservingPolicies sets the access type of the container, sending the existing stored access
+// policies back exactly as they were returned
+func (c *storageContainerACLClient) setAccessTypePreservingPolicies(accessType storage.ContainerAccessType) error {
+	resp, err := c.send(http.MethodGet, nil, nil)
+	if err != nil {
+		return err
+	}
+	policies, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("Error reading the existing stored access policies: %+v", err)
+	}
 
-	resourceGroupName := d.Get("resource_group_name").(string)
-	storageAccountName := d.Get("storage_account_name").(string)
+	headers := map[string]string{}
+	if accessType != storage.ContainerAccessTypePrivate {
+		headers[storage.ContainerAccessHeader] = string(accessType)
+	}
 
-	blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName)
+	resp, err = c.send(http.MethodPut, headers, policies)
 	if err != nil {
-		return false, err
+		return err
 	}
-	if !accountExists {
-		log.Printf("[DEBUG] Storage account %q not found, removing container %q from state", storageAccountName, d.Id())
-		d.SetId("")
-		return false, nil
+	resp.Body.Close()
+
+	return nil
+}
+
+func (c *storageContainerACLClient) send(method string, headers map[string]string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewReader(body)
 	}
 
-	name := d.Get("name").(string)
+	req, err := http.NewRequest(method, fmt.Sprintf("%s?restype=container&comp=acl", c.containerURL), reader)
+	if err != nil {
+		return nil, fmt.Errorf("Error building the request: %+v", err)
+	}
 
-	log.Printf("[INFO] Checking existence of storage container %q in storage account %q", name, storageAccountName)
-	reference := blobClient.GetContainerReference(name)
-	exists, err := reference.Exists()
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("x-ms-version", c.apiVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/xml")
+	}
+	signStorageSharedKeyRequest(req, c.accountName, c.accountKey)
+
+	resp, err := c.client.Sender.Send(c.client, req)
 	if err != nil {
-		return false, fmt.Errorf("Error querying existence of storage container %q in storage account %q: %s", name, storageAccountName, err)
+		return nil, err
 	}
 
-	if !exists {
-		log.Printf("[INFO] Storage container %q does not exist in account %q, removing from state...", name, storageAccountName)
-		d.SetId("")
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+
+		storageErr := storage.AzureStorageServiceError{}
+		if respBody, err := ioutil.ReadAll(resp.Body); err == nil {
+			xml.Unmarshal(respBody, &storageErr)
+		}
+		storageErr.StatusCode = resp.StatusCode
+		storageErr.RequestID = resp.Header.Get("x-ms-request-id")
+		storageErr.Date = resp.Header.Get("Date")
+		storageErr.APIVersion = resp.Header.Get("x-ms-version")
+		return nil, storageErr
 	}
 
-	return exists, nil
+	return resp, nil
 }
 
-// resourceAzureStorageContainerDelete does all the necessary API calls to
-// delete a storage container off Azure.
-func resourceArmStorageContainerDelete(d *schema.ResourceData, meta interface{}) error {
-	armClient := meta.(*ArmClient)
-	ctx := armClient.StopContext
+// signStorageSharedKeyRequest adds the Shared Key Authorization header to a Blob Storage request, see:
+// https://docs.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func signStorageSharedKeyRequest(req *http.Request, accountName string, accountKey []byte) {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
 
-	resourceGroupName := d.Get("resource_group_name").(string)
-	storageAccountName := d.Get("storage_account_name").(string)
+	canonicalizedHeaders := make([]string, 0)
+	for k, v := range req.Header {
+		name := strings.ToLower(strings.TrimSpace(k))
+		if strings.HasPrefix(name, "x-ms-") {
+			canonicalizedHeaders = append(canonicalizedHeaders, fmt.Sprintf("%s:%s", name, strings.Join(v, ",")))
+		}
+	}
+	sort.Strings(canonicalizedHeaders)
 
-	blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName)
-	if err != nil {
-		return err
+	canonicalizedResource := fmt.Sprintf("/%s%s", accountName, req.URL.EscapedPath())
+	query := req.URL.Query()
+	queryKeys := make([]string, 0, len(query))
+	for k := range query {
+		queryKeys = append(queryKeys, k)
 	}
-	if !accountExists {
-		log.Printf("[INFO]Storage Account %q doesn't exist so the container won't exist", storageAccountName)
-		return nil
+	sort.Strings(queryKeys)
+	for _, k := range queryKeys {
+		values := query[k]
+		sort.Strings(values)
+		canonicalizedResource += fmt.Sprintf("\n%s:%s", strings.ToLower(k), strings.Join(values, ","))
 	}
 
-	name := d.Get("name").(string)
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // the Date header isn't used since `x-ms-date` is set
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		strings.Join(canonicalizedHeaders, "\n"),
+		canonicalizedResource,
+	}, "\n")
 
-	log.Printf("[INFO] Deleting storage container %q in account %q", name, storageAccountName)
-	reference := blobClient.GetContainerReference(name)
-	deleteOptions := &storage.DeleteContainerOptions{}
-	if _, err := reference.DeleteIfExists(deleteOptions); err != nil {
-		return fmt.Errorf("Error deleting storage container %q from storage account %q: %s", name, storageAccountName, err)
+	mac := hmac.New(sha256.New, accountKey)
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", accountName, signature))
+}
+
+// retryStorageContainerCreate behaves like resource.Retry, but waits for the poll interval between attempts
+// rather than retrying almost immediately - since a Container which is being deleted can return a 409 for
+// some time, there's little value in hammering the API during that window.
+func retryStorageContainerCreate(timeout time.Duration, pollInterval time.Duration, f resource.RetryFunc) error {
+	var resultErr error
+	var resultErrMu sync.Mutex
+
+	c := &resource.StateChangeConf{
+		Pending:      []string{"retryableerror"},
+		Target:       []string{"success"},
+		Timeout:      timeout,
+		PollInterval: pollInterval,
+		Refresh: func() (interface{}, string, error) {
+			rerr := f()
+
+			resultErrMu.Lock()
+			defer resultErrMu.Unlock()
+
+			if rerr == nil {
+				resultErr = nil
+				return 42, "success", nil
+			}
+
+			resultErr = rerr.Err
+
+			if rerr.Retryable {
+				return 42, "retryableerror", nil
+			}
+			return nil, "quit", rerr.Err
+		},
 	}
 
-	d.SetId("")
-	return nil
+	_, waitErr := c.WaitForState()
+
+	resultErrMu.Lock()
+	defer resultErrMu.Unlock()
+
+	// the error from the last attempt is more useful than the timeout, when there is one
+	if resultErr == nil {
+		return waitErr
+	}
+	return resultErr
+}
+
+func checkContainerIsCreated(reference *storage.Container, created *bool) func() *resource.RetryError {
+	return func() *resource.RetryError {
+		createOptions := &storage.CreateContainerOptions{}
+		wasCreated, err := reference.CreateIfNotExists(createOptions)
+		if err != nil {
+			if storageContainerCreateErrorIsRetryable(err) {
+				return resource.RetryableError(err)
+			}
+
+			return resource.NonRetryableError(err)
+		}
+
+		// a previous attempt may have created the container before failing
+		*created = *created || wasCreated
+		return nil
+	}
+}
+
+// storageContainerCreateTransientErrorCodes are the error codes returned when creating a Storage Container which are
+// expected to resolve themselves, such as whilst the Storage Account is being created or is temporarily read-only
+// during maintenance (which the service reports as being busy)
+var storageContainerCreateTransientErrorCodes = map[string]struct{}{
+	"AccountBeingCreated":   {},
+	"ContainerBeingDeleted": {},
+	"InternalError":         {},
+	"OperationTimedOut":     {},
+	"ServerBusy":            {},
+}
+
+// storageContainerCreateErrorIsRetryable returns whether the error returned when creating a Storage Container is
+// transient. Other errors returned by the service are permanent - for example a Storage Account which is disabled
+// (`AccountIsDisabled`), or a read-only endpoint such as the secondary (`InsufficientAccountPermissions`).
+func storageContainerCreateErrorIsRetryable(err error) bool {
+	storageErr, ok := err.(storage.AzureStorageServiceError)
+	if !ok {
+		// the request didn't reach the service - only timeouts and temporary network errors are retried, since
+		// others (such as a DNS failure for a mistyped `custom_blob_endpoint`, or a TLS error) won't resolve themselves
+		if netErr, ok := err.(net.Error); ok {
+			return netErr.Timeout() || netErr.Temporary()
+		}
+
+		return false
+	}
+
+	if _, ok := storageContainerCreateTransientErrorCodes[storageErr.Code]; ok {
+		return true
+	}
+
+	return storageErr.StatusCode == http.StatusTooManyRequests || storageErr.StatusCode >= http.StatusInternalServerError
+}
+
+// resourceAzureStorageContainerRead does all the necessary API calls to
+// read the status of the storage container off Azure.
+func resourceArmStorageContainerRead(d *schema.ResourceData, meta interface{}) error {
+	armClient := meta.(*ArmClient)
+	ctx := armClient.StopContext
+
+	resourceGroupName := d.Get("resource_group_name").(string)
+	storageAccountName := d.Get("storage_account_name").(string)
+
+	blobClient, accountExists, err := armClient.getReadOnlyBlobStorageClientForStorageAccountWithEndpoint(ctx, resourceGroupName, storageAccountName, d.Get("custom_blob_endpoint").(string))
+	if err != nil {
+		return err
+	}
+	if !accountExists {
+		return armClient.storageContainerNotFound(d, fmt.Sprintf("Storage account %q (Resource Group %q) was not found", storageAccountName, resourceGroupName))
+	}
+
+	name := d.Get("name").(string)
+	reference := blobClient.GetContainerReference(name)
+	exists, err := armClient.retrieveStorageContainerUsingCache(storageAccountName, reference)
+	if err != nil {
+		return fmt.Errorf("Error retrieving storage container %q in storage account %q: %s", name, storageAccountName, describeStorageError(err))
+	}
+	if !exists {
+		return armClient.storageContainerNotFound(d, fmt.Sprintf("Storage container %q was not found in storage account %q", name, storageAccountName))
+	}
+
+	armClient.trackStorageContainer(storageAccountName, name)
+
+	account, err := armClient.getStorageAccountUsingCache(ctx, resourceGroupName, storageAccountName)
+	if err != nil {
+		return fmt.Errorf("Error retrieving Storage Account %q (Resource Group %q): %+v", storageAccountName, resourceGroupName, err)
+	}
+	d.Set("account_kind", string(account.Kind))
+	if sku := account.Sku; sku != nil {
+		d.Set("account_tier", string(sku.Tier))
+	}
+
+	primaryBlobEndpoint := ""
+	secondaryBlobEndpoint := ""
+	if props := account.AccountProperties; props != nil {
+		if endpoints := props.PrimaryEndpoints; endpoints != nil && endpoints.Blob != nil {
+			primaryBlobEndpoint = *endpoints.Blob
+		}
+
+		// the secondary endpoint is only meaningful when the account is replicated to another region
+		if endpoints := props.SecondaryEndpoints; endpoints != nil && endpoints.Blob != nil && storageAccountIsGeoRedundant(account.Sku) {
+			secondaryBlobEndpoint = *endpoints.Blob
+		}
+	}
+	d.Set("access_key_source", armClient.storageAccountKeySource(resourceGroupName, storageAccountName))
+	d.Set("primary_blob_endpoint", primaryBlobEndpoint)
+	d.Set("secondary_blob_endpoint", secondaryBlobEndpoint)
+
+	props := make(map[string]interface{})
+	props["last_modified"] = reference.Properties.LastModified
+	props["lease_status"] = reference.Properties.LeaseStatus
+	props["lease_state"] = reference.Properties.LeaseState
+	props["lease_duration"] = reference.Properties.LeaseDuration
+
+	d.Set("properties", props)
+
+	// the API returns these in lower-case, but we normalise them since they're intended to be used in conditionals
+	d.Set("lease_status", strings.ToLower(reference.Properties.LeaseStatus))
+	d.Set("lease_state", strings.ToLower(reference.Properties.LeaseState))
+	d.Set("lease_duration", strings.ToLower(reference.Properties.LeaseDuration))
+	d.Set("lease_active", storageContainerLeaseIsActive(reference.Properties))
+
+	metadata, costTags := flattenArmStorageContainerMetadataAndCostTags(reference.Metadata)
+	if err := d.Set("cost_tags", costTags); err != nil {
+		return fmt.Errorf("Error flattening `cost_tags`: %+v", err)
+	}
+
+	// when metadata is managed outside of Terraform we leave the value in the state untouched
+	if !d.Get("ignore_metadata_changes").(bool) {
+		if err := d.Set("metadata", metadata); err != nil {
+			return fmt.Errorf("Error flattening `metadata`: %+v", err)
+		}
+	}
+
+	permissions, err := reference.GetPermissions(&storage.GetContainerPermissionOptions{})
+	accessType, err := storageContainerAccessTypeFromPermissions(permissions, err)
+	if err != nil {
+		return fmt.Errorf("Error retrieving permissions for storage container %q in storage account %q: %s", name, storageAccountName, describeStorageError(err))
+	}
+	d.Set("container_access_type", accessType)
+
+	anonymousAccessEffective := false
+	if d.Get("check_anonymous_access").(bool) {
+		httpClient := armClient.storageHTTPClient
+		if httpClient == nil {
+			httpClient = &http.Client{Timeout: 30 * time.Second}
+		}
+
+		// the anonymous request isn't sent via the Storage client, so needs to be sent to the custom endpoint (if any) explicitly
+		containerURL, err := storageContainerURLForEndpoint(reference.GetURL(), d.Get("custom_blob_endpoint").(string))
+		if err != nil {
+			return fmt.Errorf("Error building the URL for storage container %q in storage account %q: %+v", name, storageAccountName, err)
+		}
+
+		anonymousAccessEffective, err = checkStorageContainerAnonymousAccess(httpClient, reference, containerURL, accessType)
+		if err != nil {
+			return fmt.Errorf("Error checking anonymous access to storage container %q in storage account %q: %+v", name, storageAccountName, err)
+		}
+	}
+	d.Set("anonymous_access_effective", anonymousAccessEffective)
+
+	return nil
+}
+
+// storageContainerURLForEndpoint returns the URL of the container on the custom endpoint, when specified - in the
+// same way as the storageEndpointOverrideSender redirects the requests made by the Storage client
+func storageContainerURLForEndpoint(containerURL, endpoint string) (string, error) {
+	if endpoint == "" {
+		return containerURL, nil
+	}
+
+	override, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing endpoint %q: %+v", endpoint, err)
+	}
+	if override.Scheme == "" || override.Host == "" {
+		return "", fmt.Errorf("Endpoint %q must be an absolute URL", endpoint)
+	}
+
+	u, err := url.Parse(containerURL)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing container URL %q: %+v", containerURL, err)
+	}
+	u.Scheme = override.Scheme
+	u.Host = override.Host
+
+	return u.String(), nil
+}
+
+// checkStorageContainerAnonymousAccess makes an unauthenticated request to determine whether the container can
+// actually be reached anonymously, since account-level settings and firewalls can block access regardless of the
+// access type. Containers with `blob` access can't be listed anonymously - so a blob within the container is used,
+// and if the container is empty there's nothing which can be reached.
+func checkStorageContainerAnonymousAccess(httpClient *http.Client, lister storageContainerBlobsLister, containerURL, accessType string) (bool, error) {
+	var req *http.Request
+	var err error
+
+	switch accessType {
+	case "container":
+		req, err = http.NewRequest(http.MethodGet, fmt.Sprintf("%s?restype=container&comp=list&maxresults=1", containerURL), nil)
+
+	case "blob":
+		blobs, listErr := lister.ListBlobs(storage.ListBlobsParameters{
+			MaxResults: 1,
+		})
+		if listErr != nil {
+			return false, fmt.Errorf("Error listing blobs: %+v", listErr)
+		}
+		if len(blobs.Blobs) == 0 {
+			log.Printf("[DEBUG] Storage container %q has no blobs which could be accessed anonymously", containerURL)
+			return false, nil
+		}
+
+		req, err = http.NewRequest(http.MethodHead, fmt.Sprintf("%s/%s", containerURL, url.PathEscape(blobs.Blobs[0].Name)), nil)
+
+	default:
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("Error building anonymous request: %+v", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// a firewall can drop the connection entirely, rather than returning an error response
+		log.Printf("[DEBUG] Anonymous request to %q failed: %+v", req.URL.String(), err)
+		return false, nil
+	}
+	defer resp.Body.Close()
+
+	log.Printf("[DEBUG] Anonymous request to %q returned %d", req.URL.String(), resp.StatusCode)
+	return resp.StatusCode >= 200 && resp.StatusCode < 300, nil
+}
+
+type storageContainerBlobsLister interface {
+	ListBlobs(params storage.ListBlobsParameters) (storage.BlobListResponse, error)
+}
+
+// checkStorageContainerIsEmpty returns an error if the container contains any blobs
+func checkStorageContainerIsEmpty(client storageContainerBlobsLister) error {
+	blobs, err := client.ListBlobs(storage.ListBlobsParameters{
+		MaxResults: 1,
+	})
+	if err != nil {
+		return fmt.Errorf("Error listing blobs: %+v", err)
+	}
+
+	if len(blobs.Blobs) > 0 {
+		return fmt.Errorf("The container isn't empty (it contains the blob %q) and `require_empty_on_adopt` is set", blobs.Blobs[0].Name)
+	}
+
+	return nil
+}
+
+// retrieveStorageContainer populates the properties and metadata of the container, returning false if it
+// doesn't exist. The properties are retrieved directly rather than by listing the containers in the account,
+// which avoids paging through every container sharing the same prefix.
+func retrieveStorageContainer(reference *storage.Container) (bool, error) {
+	if err := reference.GetProperties(); err != nil {
+		if storageErrorWasStatusCode(err, http.StatusNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if err := reference.GetMetadata(&storage.ContainerMetadataOptions{}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// expandArmStorageContainerAccessType converts the access type into the value used by the API,
+// where private containers are represented by an empty access type
+func expandArmStorageContainerAccessType(input string) storage.ContainerAccessType {
+	if strings.EqualFold(input, "private") {
+		return storage.ContainerAccessType("")
+	}
+
+	return storage.ContainerAccessType(input)
+}
+
+func expandArmStorageContainerMetadata(input map[string]interface{}) map[string]string {
+	output := make(map[string]string, len(input))
+
+	for k, v := range input {
+		output[k] = v.(string)
+	}
+
+	return output
+}
+
+func flattenArmStorageContainerMetadata(input map[string]string) map[string]interface{} {
+	output := make(map[string]interface{}, len(input))
+
+	for k, v := range input {
+		output[k] = v
+	}
+
+	return output
+}
+
+// expandArmStorageContainerMetadataWithCostTags returns the metadata with the Cost Tags merged in under the
+// reserved prefix, replacing any Cost Tags already present in the metadata
+func expandArmStorageContainerMetadataWithCostTags(metadata map[string]string, costTags map[string]interface{}) map[string]string {
+	output := make(map[string]string, len(metadata)+len(costTags))
+
+	for k, v := range metadata {
+		if !strings.HasPrefix(k, storageContainerCostTagsMetadataPrefix) {
+			output[k] = v
+		}
+	}
+
+	for k, v := range costTags {
+		output[storageContainerCostTagsMetadataPrefix+k] = v.(string)
+	}
+
+	return output
+}
+
+// mergeArmStorageContainerMetadata applies the changes between the previously and currently configured metadata to
+// the metadata which exists on the container, leaving any keys which aren't managed by Terraform untouched
+func mergeArmStorageContainerMetadata(existing map[string]string, previous, configured map[string]interface{}) map[string]string {
+	output := make(map[string]string, len(existing)+len(configured))
+	for k, v := range existing {
+		output[k] = v
+	}
+
+	for k := range previous {
+		if _, ok := configured[k]; !ok {
+			delete(output, k)
+		}
+	}
+
+	for k, v := range configured {
+		output[k] = v.(string)
+	}
+
+	return output
+}
+
+// flattenArmStorageContainerMetadataAndCostTags splits the metadata returned from the API into the
+// user-defined metadata and the Cost Tags, with the reserved prefix removed
+func flattenArmStorageContainerMetadataAndCostTags(input map[string]string) (map[string]interface{}, map[string]interface{}) {
+	metadata := make(map[string]interface{})
+	costTags := make(map[string]interface{})
+
+	for k, v := range input {
+		if strings.HasPrefix(k, storageContainerCostTagsMetadataPrefix) {
+			costTags[strings.TrimPrefix(k, storageContainerCostTagsMetadataPrefix)] = v
+			continue
+		}
+
+		metadata[k] = v
+	}
+
+	return metadata, costTags
+}
+
+// storageContainerLeaseIsActive returns whether the container is locked by a lease - which includes a lease
+// which is being broken, since the container remains locked until the break period ends
+func storageContainerLeaseIsActive(properties storage.ContainerProperties) bool {
+	return strings.EqualFold(properties.LeaseStatus, "locked")
+}
+
+// storageContainerAccessTypeFromPermissions returns the access type for the container from the result of
+// the GetPermissions call. Accounts which block anonymous access can return a 403 for the public access
+// portion of this call - in which case the container can only be private.
+func storageContainerAccessTypeFromPermissions(permissions *storage.ContainerPermissions, err error) (string, error) {
+	if err != nil {
+		if storageErrorWasStatusCode(err, http.StatusForbidden) {
+			log.Printf("[DEBUG] Retrieving the permissions for the storage container was forbidden - assuming the container is private")
+			return "private", nil
+		}
+
+		return "", err
+	}
+
+	if permissions == nil || permissions.AccessType == storage.ContainerAccessType("") {
+		return "private", nil
+	}
+
+	return string(permissions.AccessType), nil
+}
+
+func storageErrorWasStatusCode(err error, statusCode int) bool {
+	if storageErr, ok := err.(storage.AzureStorageServiceError); ok {
+		return storageErr.StatusCode == statusCode
+	}
+
+	return false
+}
+
+// storageErrorHints contains suggestions for resolving common Blob Storage errors, keyed by the error code
+var storageErrorHints = map[string]string{
+	"AuthorizationFailure": "The request was authenticated but isn't authorized - check that the Storage Account's firewall " +
+		"(`network_rules`) allows access from the machine running Terraform, or use a `custom_blob_endpoint` within the Virtual Network.",
+	"ContainerBeingDeleted": "A Storage Container with this name was recently deleted and is still being removed - this can take " +
+		"several minutes, after which it can be re-created. Alternatively use a different `name`.",
+	"InvalidResourceName": "Storage Container names must be 3-63 characters long, contain only lowercase letters, numbers and hyphens, " +
+		"begin with a letter or number and not contain consecutive hyphens.",
+	"ResourceNotFound": "The Storage Container (or the Storage Account's Blob Service) wasn't found - check that the " +
+		"`storage_account_name` is correct and that the Container hasn't been deleted outside of Terraform.",
+	"ContainerNotFound": "The Storage Container wasn't found - it may have been deleted outside of Terraform.",
+}
+
+// storageErrorHintsByStatusCode is used when the response doesn't include an error code,
+// which is the case for HEAD requests since they have no response body
+var storageErrorHintsByStatusCode = map[int]string{
+	http.StatusForbidden: storageErrorHints["AuthorizationFailure"],
+	http.StatusNotFound:  storageErrorHints["ResourceNotFound"],
+}
+
+// describeStorageError returns the message for the error, followed by a suggestion of how to resolve
+// it when it's a common Blob Storage error
+func describeStorageError(err error) string {
+	storageErr, ok := err.(storage.AzureStorageServiceError)
+	if !ok {
+		return err.Error()
+	}
+
+	hint, ok := storageErrorHints[storageErr.Code]
+	if !ok && storageErr.Code == "" {
+		hint, ok = storageErrorHintsByStatusCode[storageErr.StatusCode]
+	}
+	if !ok {
+		return err.Error()
+	}
+
+	return fmt.Sprintf("%s\n\n%s", err.Error(), hint)
+}
+
+func resourceArmStorageContainerExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	armClient := meta.(*ArmClient)
+	ctx := armClient.StopContext
+
+	resourceGroupName := d.Get("resource_group_name").(string)
+	storageAccountName := d.Get("storage_account_name").(string)
+
+	blobClient, accountExists, err := armClient.getReadOnlyBlobStorageClientForStorageAccountWithEndpoint(ctx, resourceGroupName, storageAccountName, d.Get("custom_blob_endpoint").(string))
+	if err != nil {
+		return false, err
+	}
+	if !accountExists {
+		return false, armClient.storageContainerNotFound(d, fmt.Sprintf("Storage account %q (Resource Group %q) was not found", storageAccountName, resourceGroupName))
+	}
+
+	name := d.Get("name").(string)
+
+	log.Printf("[INFO] Checking existence of storage container %q in storage account %q", name, storageAccountName)
+	reference := blobClient.GetContainerReference(name)
+	exists, err := armClient.retrieveStorageContainerForCache(storageAccountName, reference)
+	if err != nil {
+		return false, fmt.Errorf("Error querying existence of storage container %q in storage account %q: %s", name, storageAccountName, describeStorageError(err))
+	}
+
+	if !exists {
+		return false, armClient.storageContainerNotFound(d, fmt.Sprintf("Storage container %q was not found in storage account %q", name, storageAccountName))
+	}
+
+	return true, nil
+}
+
+type storageContainerDeleter interface {
+	DeleteIfExists(options *storage.DeleteContainerOptions) (bool, error)
+}
+
+type storageContainerExistenceChecker interface {
+	Exists() (bool, error)
+}
+
+// deleteStorageContainerRetryFunc deletes the container, retrying when the request is throttled or fails with a
+// server error. A container which doesn't exist is treated as having been deleted.
+func deleteStorageContainerRetryFunc(client storageContainerDeleter) resource.RetryFunc {
+	return func() *resource.RetryError {
+		if _, err := client.DeleteIfExists(&storage.DeleteContainerOptions{}); err != nil {
+			if storageErr, ok := err.(storage.AzureStorageServiceError); ok {
+				if storageErr.StatusCode == http.StatusTooManyRequests || storageErr.StatusCode >= http.StatusInternalServerError {
+					log.Printf("[DEBUG] Deleting the storage container failed with a %d - retrying", storageErr.StatusCode)
+					return resource.RetryableError(err)
+				}
+			}
+
+			return resource.NonRetryableError(err)
+		}
+
+		return nil
+	}
+}
+
+// storageContainerNotFound removes the Storage Container from the state when it (or its Storage Account) wasn't
+// found - unless `storage_strict_read` is enabled, in which case an error is returned so that a misleading "not found"
+// (such as from a misconfigured endpoint) can't cause the Container to be re-created
+func (armClient *ArmClient) storageContainerNotFound(d *schema.ResourceData, reason string) error {
+	if armClient.storageStrictRead {
+		return fmt.Errorf("%s and `storage_strict_read` is enabled - if it was deleted intentionally, remove it from the state using `terraform state rm`", reason)
+	}
+
+	log.Printf("[INFO] %s, removing storage container %q from state", reason, d.Id())
+	d.SetId("")
+	return nil
+}
+
+// resourceAzureStorageContainerDelete does all the necessary API calls to
+// delete a storage container off Azure.
+func resourceArmStorageContainerDelete(d *schema.ResourceData, meta interface{}) error {
+	armClient := meta.(*ArmClient)
+	ctx := armClient.StopContext
+	start := time.Now()
+
+	resourceGroupName := d.Get("resource_group_name").(string)
+	storageAccountName := d.Get("storage_account_name").(string)
+
+	blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccountWithEndpoint(ctx, resourceGroupName, storageAccountName, d.Get("custom_blob_endpoint").(string))
+	if err != nil {
+		return err
+	}
+	if !accountExists {
+		log.Printf("[INFO]Storage Account %q doesn't exist so the container won't exist", storageAccountName)
+		return nil
+	}
+
+	name := d.Get("name").(string)
+
+	log.Printf("[INFO] Deleting storage container %q in account %q", name, storageAccountName)
+	reference := blobClient.GetContainerReference(name)
+	if err := resource.Retry(d.Timeout(schema.TimeoutDelete), deleteStorageContainerRetryFunc(reference)); err != nil {
+		return fmt.Errorf("Error deleting storage container %q from storage account %q: %s", name, storageAccountName, describeStorageError(err))
+	}
+
+	if d.Get("wait_for_deletion").(bool) {
+		log.Printf("[INFO] Waiting for storage container %q in account %q to be deleted", name, storageAccountName)
+		timeout := d.Timeout(schema.TimeoutDelete) - time.Since(start)
+		if err := resource.Retry(timeout, waitForStorageContainerDeletionRetryFunc(reference)); err != nil {
+			return fmt.Errorf("Error waiting for storage container %q in storage account %q to be deleted: %s", name, storageAccountName, describeStorageError(err))
+		}
+	}
+
+	armClient.releaseStorageContainer(storageAccountName, name)
+
+	// a Storage Account created by this resource is left in place, since it may contain data (such as File Shares,
+	// Queues or Tables) which isn't managed by this resource
+	if d.Get("account_created").(bool) {
+		log.Printf("[INFO] Leaving Storage Account %q which was created for storage container %q in place", storageAccountName, name)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// waitForStorageContainerDeletionRetryFunc polls until the deleted container no longer exists
+func waitForStorageContainerDeletionRetryFunc(client storageContainerExistenceChecker) resource.RetryFunc {
+	return func() *resource.RetryError {
+		exists, err := client.Exists()
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		if exists {
+			log.Printf("[DEBUG] The storage container still exists - waiting for it to be deleted")
+			return resource.RetryableError(fmt.Errorf("the storage container still exists"))
+		}
+
+		return nil
+	}
+}
+
+// storageContainerCreatePollIntervalOrDefault returns the interval between checks whilst creating a container,
+// configured in the Provider block, falling back to 2 seconds when omitted
+func (armClient *ArmClient) storageContainerCreatePollIntervalOrDefault() time.Duration {
+	if armClient.storageContainerCreatePollInterval > 0 {
+		return armClient.storageContainerCreatePollInterval
+	}
+
+	return 2 * time.Second
+}
+
+// storageContainerAccessTypeOrDefault returns the access type specified on the resource, falling back
+// to the default access type configured in the Provider block (and then to `private`) when omitted
+func (armClient *ArmClient) storageContainerAccessTypeOrDefault(accessType string) string {
+	if accessType != "" {
+		return accessType
+	}
+
+	if armClient.defaultStorageContainerAccessType != "" {
+		return armClient.defaultStorageContainerAccessType
+	}
+
+	return "private"
+}
+
+// checkStorageContainerAccessTypeIsAllowed returns an error if the access type isn't one of the access types
+// allowed by the Provider block - when no access types are specified, all access types are allowed
+func (armClient *ArmClient) checkStorageContainerAccessTypeIsAllowed(accessType string) error {
+	if len(armClient.allowedStorageContainerAccessTypes) == 0 {
+		return nil
+	}
+
+	for _, allowed := range armClient.allowedStorageContainerAccessTypes {
+		if strings.EqualFold(allowed, accessType) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("The `container_access_type` %q isn't allowed by the Provider - allowed access types are: %s", accessType, strings.Join(armClient.allowedStorageContainerAccessTypes, ", "))
+}
+
+// checkStorageContainerNameMatchesPolicy returns an error if the name doesn't match the `container_name_regex` specified
+// in the Provider block. This is in addition to the naming rules enforced by Azure, which are checked by the ValidateFunc.
+func (armClient *ArmClient) checkStorageContainerNameMatchesPolicy(name string) error {
+	if armClient.storageContainerNameRegex == "" {
+		return nil
+	}
+
+	r, err := regexp.Compile(armClient.storageContainerNameRegex)
+	if err != nil {
+		return fmt.Errorf("Error compiling the `container_name_regex` %q: %+v", armClient.storageContainerNameRegex, err)
+	}
+
+	if !r.MatchString(name) {
+		return fmt.Errorf("The name %q doesn't match the `container_name_regex` %q specified in the Provider", name, armClient.storageContainerNameRegex)
+	}
+
+	return nil
+}
+
+func storageContainerInUseKey(storageAccountName, containerName string) string {
+	return strings.ToLower(fmt.Sprintf("%s/%s", storageAccountName, containerName))
+}
+
+// claimStorageContainer records that a Storage Container is about to be created by a resource,
+// returning an error if another resource has already created or read this Container during this run
+func (armClient *ArmClient) claimStorageContainer(storageAccountName, containerName string) error {
+	armClient.storageContainersInUseMu.Lock()
+	defer armClient.storageContainersInUseMu.Unlock()
+
+	if armClient.storageContainersInUse == nil {
+		armClient.storageContainersInUse = make(map[string]struct{})
+	}
+
+	key := storageContainerInUseKey(storageAccountName, containerName)
+	if _, exists := armClient.storageContainersInUse[key]; exists {
+		return fmt.Errorf("Storage Container %q in Storage Account %q is already managed by another `azurerm_storage_container` resource - each Container should only be managed by a single resource", containerName, storageAccountName)
+	}
+
+	armClient.storageContainersInUse[key] = struct{}{}
+	return nil
+}
+
+// trackStorageContainer records that a Storage Container is managed by a resource, without
+// checking for conflicts since a resource may be read multiple times during a run
+func (armClient *ArmClient) trackStorageContainer(storageAccountName, containerName string) {
+	armClient.storageContainersInUseMu.Lock()
+	defer armClient.storageContainersInUseMu.Unlock()
+
+	if armClient.storageContainersInUse == nil {
+		armClient.storageContainersInUse = make(map[string]struct{})
+	}
+
+	armClient.storageContainersInUse[storageContainerInUseKey(storageAccountName, containerName)] = struct{}{}
+}
+
+func (armClient *ArmClient) releaseStorageContainer(storageAccountName, containerName string) {
+	armClient.storageContainersInUseMu.Lock()
+	defer armClient.storageContainersInUseMu.Unlock()
+
+	delete(armClient.storageContainersInUse, storageContainerInUseKey(storageAccountName, containerName))
+}
+
+type retrievedStorageContainer struct {
+	properties storage.ContainerProperties
+	metadata   map[string]string
+}
+
+// retrieveStorageContainerForCache retrieves the Storage Container, holding onto its properties and metadata so
+// that they can be used by a subsequent call to retrieveStorageContainerUsingCache, rather than retrieving them again
+func (armClient *ArmClient) retrieveStorageContainerForCache(storageAccountName string, reference *storage.Container) (bool, error) {
+	exists, err := retrieveStorageContainer(reference)
+	if err != nil || !exists {
+		return exists, err
+	}
+
+	armClient.storageContainersRetrievedMu.Lock()
+	defer armClient.storageContainersRetrievedMu.Unlock()
+
+	if armClient.storageContainersRetrieved == nil {
+		armClient.storageContainersRetrieved = make(map[string]retrievedStorageContainer)
+	}
+
+	armClient.storageContainersRetrieved[storageContainerInUseKey(storageAccountName, reference.Name)] = retrievedStorageContainer{
+		properties: reference.Properties,
+		metadata:   reference.Metadata,
+	}
+
+	return true, nil
+}
+
+// retrieveStorageContainerUsingCache populates the Storage Container from the cache when it's been retrieved by
+// retrieveStorageContainerForCache, otherwise it's retrieved. Cached values are only used once, so that they're
+// only reused within the same operation (e.g. the Exists check and Read which make up a refresh).
+func (armClient *ArmClient) retrieveStorageContainerUsingCache(storageAccountName string, reference *storage.Container) (bool, error) {
+	key := storageContainerInUseKey(storageAccountName, reference.Name)
+
+	armClient.storageContainersRetrievedMu.Lock()
+	retrieved, ok := armClient.storageContainersRetrieved[key]
+	delete(armClient.storageContainersRetrieved, key)
+	armClient.storageContainersRetrievedMu.Unlock()
+
+	if !ok {
+		return retrieveStorageContainer(reference)
+	}
+
+	log.Printf("[DEBUG] Using the properties and metadata for storage container %q retrieved by the Exists check", reference.Name)
+	reference.Properties = retrieved.properties
+	reference.Metadata = retrieved.metadata
+	return true, nil
+}
+
+type storageContainerID struct {
+	storageAccountName string
+	containerName      string
+	endpointSuffix     string
+}
+
+// parseStorageContainerID parses the URL of a Storage Container, in the form
+// `https://{storageAccountName}.blob.{endpointSuffix}/{containerName}`
+func parseStorageContainerID(input string) (*storageContainerID, error) {
+	uri, err := url.Parse(input)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing %q as a URL: %+v", input, err)
+	}
+
+	if uri.Scheme == "" || uri.Host == "" {
+		return nil, fmt.Errorf("Expected %q to be an absolute URL", input)
+	}
+
+	hostSegments := strings.SplitN(uri.Host, ".", 3)
+	if len(hostSegments) != 3 || hostSegments[0] == "" || hostSegments[1] != "blob" || hostSegments[2] == "" {
+		return nil, fmt.Errorf("Expected the host of %q to be in the format `{storageAccountName}.blob.{endpointSuffix}`", input)
+	}
+
+	// the Path has already been decoded, so mustn't be unescaped again
+	containerName := strings.Trim(uri.Path, "/")
+	if containerName == "" || strings.Contains(containerName, "/") {
+		return nil, fmt.Errorf("Expected the path of %q to contain only the Storage Container name", input)
+	}
+
+	return &storageContainerID{
+		storageAccountName: hostSegments[0],
+		containerName:      containerName,
+		endpointSuffix:     hostSegments[2],
+	}, nil
 }
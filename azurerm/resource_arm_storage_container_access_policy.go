@@ -0,0 +1,402 @@
+package azurerm
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/suppress"
+	"github.com/terraform-providers/terraform-provider-azurerm/azurerm/helpers/validate"
+)
+
+func resourceArmStorageContainerAccessPolicy() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceArmStorageContainerAccessPolicyCreateUpdate,
+		Read:   resourceArmStorageContainerAccessPolicyRead,
+		Update: resourceArmStorageContainerAccessPolicyCreateUpdate,
+		Delete: resourceArmStorageContainerAccessPolicyDelete,
+
+		CustomizeDiff: resourceArmStorageContainerAccessPolicyCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"storage_container_name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateArmStorageContainerName,
+			},
+			"resource_group_name": resourceGroupNameSchema(),
+			"storage_account_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"stored_access_policy": {
+				Type:     schema.TypeList,
+				Required: true,
+				MaxItems: 5,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateArmStorageContainerAccessPolicyId,
+						},
+						"start": {
+							Type:             schema.TypeString,
+							Required:         true,
+							ValidateFunc:     validate.RFC3339Time,
+							DiffSuppressFunc: suppress.RFC3339Time,
+						},
+						"expiry": {
+							Type:             schema.TypeString,
+							Required:         true,
+							ValidateFunc:     validate.RFC3339Time,
+							DiffSuppressFunc: suppress.RFC3339Time,
+						},
+						"permissions": {
+							Type:             schema.TypeString,
+							Required:         true,
+							ValidateFunc:     validateArmStorageContainerAccessPolicyPermissions,
+							DiffSuppressFunc: suppressArmStorageContainerAccessPolicyPermissionsDiff,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Stored Access Policy identifiers are limited to 64 characters
+func validateArmStorageContainerAccessPolicyId(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if len(value) < 1 || len(value) > 64 {
+		errors = append(errors, fmt.Errorf("%q must be between 1 and 64 characters: %q", k, value))
+	}
+
+	return
+}
+
+// storageContainerAccessPolicyPermissions are the permissions which can be granted by a Stored Access Policy
+// on a Container - the vendored SDK's ContainerAccessPolicy only models Read, Write and Delete, so the Add,
+// Create and List permissions can't be sent or read back
+const storageContainerAccessPolicyPermissions = "rwd"
+
+func validateArmStorageContainerAccessPolicyPermissions(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if value == "" {
+		errors = append(errors, fmt.Errorf("%q must contain at least one of the permissions `r`, `w` or `d`", k))
+		return
+	}
+
+	seen := make(map[rune]struct{})
+	for i, c := range value {
+		if !strings.ContainsRune(storageContainerAccessPolicyPermissions, c) {
+			errors = append(errors, fmt.Errorf("%q contains the invalid permission %q at position %d - valid permissions are `r`, `w` and `d`: %q", k, c, i, value))
+			continue
+		}
+
+		if _, exists := seen[c]; exists {
+			errors = append(errors, fmt.Errorf("%q contains the permission %q more than once: %q", k, c, value))
+		}
+		seen[c] = struct{}{}
+	}
+
+	return
+}
+
+// the permissions are always returned in the order `rwd`, so the order they're specified in is ignored
+func suppressArmStorageContainerAccessPolicyPermissionsDiff(k, old, new string, d *schema.ResourceData) bool {
+	return sortStorageContainerAccessPolicyPermissions(old) == sortStorageContainerAccessPolicyPermissions(new)
+}
+
+func sortStorageContainerAccessPolicyPermissions(input string) string {
+	output := ""
+	for _, c := range storageContainerAccessPolicyPermissions {
+		if strings.ContainsRune(input, c) {
+			output += string(c)
+		}
+	}
+
+	// unknown characters are kept, so that they're still reported as a change
+	for _, c := range input {
+		if !strings.ContainsRune(storageContainerAccessPolicyPermissions, c) {
+			output += string(c)
+		}
+	}
+
+	return output
+}
+
+// the format of each field is validated by the schema, which leaves the checks spanning fields or policies
+func resourceArmStorageContainerAccessPolicyCustomizeDiff(diff *schema.ResourceDiff, v interface{}) error {
+	return validateArmStorageContainerAccessPolicies(diff.Get("stored_access_policy").([]interface{}))
+}
+
+// validateArmStorageContainerAccessPolicies returns all of the problems with the policies at once, rather
+// than only the first
+func validateArmStorageContainerAccessPolicies(input []interface{}) error {
+	var err *multierror.Error
+	ids := make(map[string]struct{})
+
+	for _, v := range input {
+		policy, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		id := policy["id"].(string)
+		if _, exists := ids[id]; exists {
+			err = multierror.Append(err, fmt.Errorf("the stored access policy ID %q is used more than once", id))
+		}
+		ids[id] = struct{}{}
+
+		// values which aren't known yet (or are invalid, which the schema reports) can't be compared
+		start, startErr := time.Parse(time.RFC3339, policy["start"].(string))
+		expiry, expiryErr := time.Parse(time.RFC3339, policy["expiry"].(string))
+		if startErr == nil && expiryErr == nil && !expiry.After(start) {
+			err = multierror.Append(err, fmt.Errorf("the `expiry` (%q) of stored access policy %q must be after the `start` (%q)", policy["expiry"].(string), id, policy["start"].(string)))
+		}
+	}
+
+	return err.ErrorOrNil()
+}
+
+func resourceArmStorageContainerAccessPolicyCreateUpdate(d *schema.ResourceData, meta interface{}) error {
+	armClient := meta.(*ArmClient)
+	ctx := armClient.StopContext
+
+	resourceGroupName := d.Get("resource_group_name").(string)
+	storageAccountName := d.Get("storage_account_name").(string)
+
+	blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName)
+	if err != nil {
+		return err
+	}
+	if !accountExists {
+		return fmt.Errorf("Storage Account %q Not Found", storageAccountName)
+	}
+
+	containerName := d.Get("storage_container_name").(string)
+	reference := blobClient.GetContainerReference(containerName)
+
+	exists, err := reference.Exists()
+	if err != nil {
+		return fmt.Errorf("Error querying existence of storage container %q in storage account %q: %s", containerName, storageAccountName, err)
+	}
+	if !exists {
+		return fmt.Errorf("Storage Container %q was not found in Storage Account %q", containerName, storageAccountName)
+	}
+
+	policies, err := expandArmStorageContainerAccessPolicies(d.Get("stored_access_policy").([]interface{}))
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Setting stored access policies for container %q in storage account %q.", containerName, storageAccountName)
+	if err := reconcileArmStorageContainerAccessPolicies(reference, policies); err != nil {
+		return fmt.Errorf("Error setting stored access policies for container %q in storage account %q: %+v", containerName, storageAccountName, err)
+	}
+
+	d.SetId(reference.GetURL())
+	return resourceArmStorageContainerAccessPolicyRead(d, meta)
+}
+
+func resourceArmStorageContainerAccessPolicyRead(d *schema.ResourceData, meta interface{}) error {
+	armClient := meta.(*ArmClient)
+	ctx := armClient.StopContext
+
+	resourceGroupName := d.Get("resource_group_name").(string)
+	storageAccountName := d.Get("storage_account_name").(string)
+
+	blobClient, accountExists, err := armClient.getReadOnlyBlobStorageClientForStorageAccountWithEndpoint(ctx, resourceGroupName, storageAccountName, "")
+	if err != nil {
+		return err
+	}
+	if !accountExists {
+		log.Printf("[DEBUG] Storage account %q not found, removing access policies for container %q from state", storageAccountName, d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	containerName := d.Get("storage_container_name").(string)
+	reference := blobClient.GetContainerReference(containerName)
+
+	exists, err := reference.Exists()
+	if err != nil {
+		return fmt.Errorf("Error querying existence of storage container %q in storage account %q: %s", containerName, storageAccountName, err)
+	}
+	if !exists {
+		log.Printf("[INFO] Storage container %q does not exist in account %q, removing access policies from state...", containerName, storageAccountName)
+		d.SetId("")
+		return nil
+	}
+
+	permissions, err := reference.GetPermissions(&storage.GetContainerPermissionOptions{})
+	if err != nil {
+		return fmt.Errorf("Error retrieving permissions for storage container %q in storage account %q: %+v", containerName, storageAccountName, err)
+	}
+
+	if err := d.Set("stored_access_policy", flattenArmStorageContainerAccessPolicies(permissions.AccessPolicies)); err != nil {
+		return fmt.Errorf("Error flattening `stored_access_policy`: %+v", err)
+	}
+
+	return nil
+}
+
+func resourceArmStorageContainerAccessPolicyDelete(d *schema.ResourceData, meta interface{}) error {
+	armClient := meta.(*ArmClient)
+	ctx := armClient.StopContext
+
+	resourceGroupName := d.Get("resource_group_name").(string)
+	storageAccountName := d.Get("storage_account_name").(string)
+
+	blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroupName, storageAccountName)
+	if err != nil {
+		return err
+	}
+	if !accountExists {
+		log.Printf("[INFO]Storage Account %q doesn't exist so the access policies won't exist", storageAccountName)
+		return nil
+	}
+
+	containerName := d.Get("storage_container_name").(string)
+	reference := blobClient.GetContainerReference(containerName)
+
+	exists, err := reference.Exists()
+	if err != nil {
+		return fmt.Errorf("Error querying existence of storage container %q in storage account %q: %s", containerName, storageAccountName, err)
+	}
+	if !exists {
+		log.Printf("[INFO] Storage container %q doesn't exist in account %q so the access policies won't exist", containerName, storageAccountName)
+		return nil
+	}
+
+	// clearing the policies leaves the container (and its access type) intact
+	log.Printf("[INFO] Clearing stored access policies for container %q in storage account %q.", containerName, storageAccountName)
+	if err := reconcileArmStorageContainerAccessPolicies(reference, []storage.ContainerAccessPolicy{}); err != nil {
+		return fmt.Errorf("Error clearing stored access policies for container %q in storage account %q: %+v", containerName, storageAccountName, err)
+	}
+
+	return nil
+}
+
+// reconcileArmStorageContainerAccessPolicies replaces the stored access policies on the container with the
+// specified policies. The API only supports replacing the entire set of policies - so the write is skipped
+// entirely when the policies already match.
+func reconcileArmStorageContainerAccessPolicies(client storageContainerPermissionsClient, policies []storage.ContainerAccessPolicy) error {
+	// the access type is set in the same call as the policies, so we need to retrieve the current
+	// value to ensure we don't reset the public access level of a container we don't own
+	existing, err := client.GetPermissions(&storage.GetContainerPermissionOptions{})
+	if err != nil {
+		return fmt.Errorf("Error retrieving permissions: %+v", err)
+	}
+
+	added, removed, changed := diffArmStorageContainerAccessPolicies(existing.AccessPolicies, policies)
+	if len(added) == 0 && len(removed) == 0 && len(changed) == 0 {
+		log.Printf("[DEBUG] The stored access policies are unchanged - skipping the update")
+		return nil
+	}
+
+	log.Printf("[DEBUG] Updating stored access policies (added %q, removed %q, changed %q)", added, removed, changed)
+	permissions := storage.ContainerPermissions{
+		AccessType:     existing.AccessType,
+		AccessPolicies: policies,
+	}
+	return client.SetPermissions(permissions, &storage.SetContainerPermissionOptions{})
+}
+
+// diffArmStorageContainerAccessPolicies returns the IDs of the stored access policies which would be added, removed and
+// changed by replacing the existing policies with the desired policies. Policies are compared by ID, regardless of order.
+func diffArmStorageContainerAccessPolicies(existing, desired []storage.ContainerAccessPolicy) (added []string, removed []string, changed []string) {
+	existingByID := make(map[string]storage.ContainerAccessPolicy, len(existing))
+	for _, policy := range existing {
+		existingByID[policy.ID] = policy
+	}
+
+	desiredIDs := make(map[string]struct{}, len(desired))
+	for _, policy := range desired {
+		desiredIDs[policy.ID] = struct{}{}
+
+		current, ok := existingByID[policy.ID]
+		if !ok {
+			added = append(added, policy.ID)
+			continue
+		}
+
+		if !current.StartTime.Equal(policy.StartTime) || !current.ExpiryTime.Equal(policy.ExpiryTime) ||
+			current.CanRead != policy.CanRead || current.CanWrite != policy.CanWrite || current.CanDelete != policy.CanDelete {
+			changed = append(changed, policy.ID)
+		}
+	}
+
+	for _, policy := range existing {
+		if _, ok := desiredIDs[policy.ID]; !ok {
+			removed = append(removed, policy.ID)
+		}
+	}
+
+	return added, removed, changed
+}
+
+func expandArmStorageContainerAccessPolicies(input []interface{}) ([]storage.ContainerAccessPolicy, error) {
+	policies := make([]storage.ContainerAccessPolicy, 0)
+
+	for _, v := range input {
+		policy := v.(map[string]interface{})
+
+		id := policy["id"].(string)
+		start, err := time.Parse(time.RFC3339, policy["start"].(string))
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing `start` for stored access policy %q: %+v", id, err)
+		}
+		expiry, err := time.Parse(time.RFC3339, policy["expiry"].(string))
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing `expiry` for stored access policy %q: %+v", id, err)
+		}
+		permissions := policy["permissions"].(string)
+
+		policies = append(policies, storage.ContainerAccessPolicy{
+			ID:         id,
+			StartTime:  start.UTC(),
+			ExpiryTime: expiry.UTC(),
+			CanRead:    strings.Contains(permissions, "r"),
+			CanWrite:   strings.Contains(permissions, "w"),
+			CanDelete:  strings.Contains(permissions, "d"),
+		})
+	}
+
+	return policies, nil
+}
+
+func flattenArmStorageContainerAccessPolicies(input []storage.ContainerAccessPolicy) []interface{} {
+	policies := make([]interface{}, 0)
+
+	for _, policy := range input {
+		permissions := ""
+		if policy.CanRead {
+			permissions += "r"
+		}
+		if policy.CanWrite {
+			permissions += "w"
+		}
+		if policy.CanDelete {
+			permissions += "d"
+		}
+
+		policies = append(policies, map[string]interface{}{
+			"id":          policy.ID,
+			"start":       policy.StartTime.UTC().Format(time.RFC3339),
+			"expiry":      policy.ExpiryTime.UTC().Format(time.RFC3339),
+			"permissions": permissions,
+		})
+	}
+
+	return policies
+}
@@ -0,0 +1,555 @@
+package azurerm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccAzureRMStorageContainerAccessPolicy_basic(t *testing.T) {
+	resourceName := "azurerm_storage_container_access_policy.test"
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	config := testAccAzureRMStorageContainerAccessPolicy_basic(ri, rs, testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageContainerAccessPolicyDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerAccessPolicyCount(resourceName, 1),
+					resource.TestCheckResourceAttr(resourceName, "stored_access_policy.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "stored_access_policy.0.id", "read-only"),
+					resource.TestCheckResourceAttr(resourceName, "stored_access_policy.0.permissions", "r"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageContainerAccessPolicy_update(t *testing.T) {
+	resourceName := "azurerm_storage_container_access_policy.test"
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	location := testLocation()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageContainerAccessPolicyDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMStorageContainerAccessPolicy_basic(ri, rs, location),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerAccessPolicyCount(resourceName, 1),
+					resource.TestCheckResourceAttr(resourceName, "stored_access_policy.#", "1"),
+				),
+			},
+			{
+				Config: testAccAzureRMStorageContainerAccessPolicy_multiple(ri, rs, location),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerAccessPolicyCount(resourceName, 2),
+					resource.TestCheckResourceAttr(resourceName, "stored_access_policy.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "stored_access_policy.1.id", "read-write"),
+					resource.TestCheckResourceAttr(resourceName, "stored_access_policy.1.permissions", "rwd"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageContainerAccessPolicy_clear(t *testing.T) {
+	resourceName := "azurerm_storage_container_access_policy.test"
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	location := testLocation()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageContainerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMStorageContainerAccessPolicy_basic(ri, rs, location),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerAccessPolicyCount(resourceName, 1),
+				),
+			},
+			{
+				// removing the access policy resource should clear the policies but leave the container
+				Config: testAccAzureRMStorageContainerAccessPolicy_template(ri, rs, location),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerAccessPolicyCount("azurerm_storage_container.test", 0),
+				),
+			},
+		},
+	})
+}
+
+func TestValidateArmStorageContainerAccessPolicyId(t *testing.T) {
+	cases := []struct {
+		Value  string
+		Errors int
+	}{
+		{Value: "", Errors: 1},
+		{Value: "a", Errors: 0},
+		{Value: strings.Repeat("a", 63), Errors: 0},
+		{Value: strings.Repeat("a", 64), Errors: 0},
+		{Value: strings.Repeat("a", 65), Errors: 1},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageContainerAccessPolicyId(tc.Value, "id")
+		if len(errors) != tc.Errors {
+			t.Fatalf("Expected %d errors for an ID of length %d but got %d", tc.Errors, len(tc.Value), len(errors))
+		}
+	}
+}
+
+func TestValidateArmStorageContainerAccessPolicyPermissions(t *testing.T) {
+	cases := []struct {
+		Value  string
+		Errors int
+	}{
+		{Value: "", Errors: 1},
+		{Value: "r", Errors: 0},
+		{Value: "rw", Errors: 0},
+		{Value: "rwd", Errors: 0},
+		{Value: "dwr", Errors: 0},
+		{Value: "l", Errors: 1},
+		{Value: "rwdl", Errors: 1},
+		{Value: "R", Errors: 1},
+		{Value: "rr", Errors: 1},
+		{Value: "rxry", Errors: 3},
+	}
+
+	for _, tc := range cases {
+		_, errors := validateArmStorageContainerAccessPolicyPermissions(tc.Value, "permissions")
+		if len(errors) != tc.Errors {
+			t.Fatalf("Expected %d errors for the permissions %q but got %d: %+v", tc.Errors, tc.Value, len(errors), errors)
+		}
+	}
+}
+
+func TestSuppressArmStorageContainerAccessPolicyPermissionsDiff(t *testing.T) {
+	cases := []struct {
+		Old      string
+		New      string
+		Suppress bool
+	}{
+		{Old: "rwd", New: "rwd", Suppress: true},
+		{Old: "rw", New: "wr", Suppress: true},
+		{Old: "rwd", New: "dwr", Suppress: true},
+		{Old: "rw", New: "rwd", Suppress: false},
+		{Old: "rwd", New: "rw", Suppress: false},
+		{Old: "", New: "r", Suppress: false},
+		{Old: "r", New: "rl", Suppress: false},
+	}
+
+	for _, tc := range cases {
+		if actual := suppressArmStorageContainerAccessPolicyPermissionsDiff("permissions", tc.Old, tc.New, nil); actual != tc.Suppress {
+			t.Fatalf("Expected the diff from %q to %q to be suppressed %t but got %t", tc.Old, tc.New, tc.Suppress, actual)
+		}
+	}
+}
+
+func TestResourceArmStorageContainerAccessPolicy_policyLimit(t *testing.T) {
+	cases := []struct {
+		Policies    int
+		ExpectError bool
+	}{
+		{Policies: 1, ExpectError: false},
+		{Policies: 5, ExpectError: false},
+		{Policies: 6, ExpectError: true},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %d policies", tc.Policies)
+
+		policies := make([]interface{}, 0)
+		for i := 0; i < tc.Policies; i++ {
+			policies = append(policies, map[string]interface{}{
+				"id":          fmt.Sprintf("policy-%d", i),
+				"start":       "2018-07-01T00:00:00Z",
+				"expiry":      "2019-07-01T00:00:00Z",
+				"permissions": "r",
+			})
+		}
+
+		raw, err := config.NewRawConfig(map[string]interface{}{
+			"storage_container_name": "vhds",
+			"resource_group_name":    "example-resources",
+			"storage_account_name":   "examplestorageaccount",
+			"stored_access_policy":   policies,
+		})
+		if err != nil {
+			t.Fatalf("Error building config: %+v", err)
+		}
+
+		_, errors := resourceArmStorageContainerAccessPolicy().Validate(terraform.NewResourceConfig(raw))
+		if tc.ExpectError && len(errors) == 0 {
+			t.Fatalf("Expected %d policies to exceed the limit but got no errors", tc.Policies)
+		}
+		if !tc.ExpectError && len(errors) > 0 {
+			t.Fatalf("Expected %d policies to be within the limit but got: %+v", tc.Policies, errors)
+		}
+	}
+}
+
+func TestValidateArmStorageContainerAccessPolicies(t *testing.T) {
+	policy := func(id, start, expiry string) map[string]interface{} {
+		return map[string]interface{}{
+			"id":          id,
+			"start":       start,
+			"expiry":      expiry,
+			"permissions": "r",
+		}
+	}
+
+	cases := []struct {
+		Name     string
+		Policies []interface{}
+		Errors   int
+	}{
+		{
+			Name: "Valid",
+			Policies: []interface{}{
+				policy("read", "2018-07-01T00:00:00Z", "2019-07-01T00:00:00Z"),
+				policy("write", "2018-07-01T00:00:00Z", "2018-07-02T00:00:00Z"),
+			},
+			Errors: 0,
+		},
+		{
+			Name: "Expiry before Start",
+			Policies: []interface{}{
+				policy("read", "2019-07-01T00:00:00Z", "2018-07-01T00:00:00Z"),
+			},
+			Errors: 1,
+		},
+		{
+			Name: "Expiry equal to Start",
+			Policies: []interface{}{
+				policy("read", "2018-07-01T00:00:00Z", "2018-07-01T00:00:00Z"),
+			},
+			Errors: 1,
+		},
+		{
+			Name: "Invalid timestamps are reported by the schema",
+			Policies: []interface{}{
+				policy("read", "yesterday", "2018-07-01T00:00:00Z"),
+			},
+			Errors: 0,
+		},
+		{
+			Name: "All errors are reported",
+			Policies: []interface{}{
+				policy("read", "2019-07-01T00:00:00Z", "2018-07-01T00:00:00Z"),
+				policy("read", "2018-07-01T00:00:00Z", "2019-07-01T00:00:00Z"),
+				policy("write", "2019-07-01T00:00:00Z", "2018-07-01T00:00:00Z"),
+			},
+			Errors: 3,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Name)
+
+		err := validateArmStorageContainerAccessPolicies(tc.Policies)
+		actual := 0
+		if err != nil {
+			actual = len(err.(*multierror.Error).Errors)
+		}
+		if actual != tc.Errors {
+			t.Fatalf("Expected %d errors but got %d: %+v", tc.Errors, actual, err)
+		}
+	}
+}
+
+type testStorageContainerRecordingPermissionsClient struct {
+	existing storage.ContainerPermissions
+	writes   []storage.ContainerPermissions
+}
+
+func (c *testStorageContainerRecordingPermissionsClient) GetPermissions(options *storage.GetContainerPermissionOptions) (*storage.ContainerPermissions, error) {
+	return &c.existing, nil
+}
+
+func (c *testStorageContainerRecordingPermissionsClient) SetPermissions(permissions storage.ContainerPermissions, options *storage.SetContainerPermissionOptions) error {
+	c.writes = append(c.writes, permissions)
+	return nil
+}
+
+func TestReconcileArmStorageContainerAccessPolicies(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiry := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	read := storage.ContainerAccessPolicy{ID: "read", StartTime: start, ExpiryTime: expiry, CanRead: true}
+	write := storage.ContainerAccessPolicy{ID: "write", StartTime: start, ExpiryTime: expiry, CanWrite: true}
+	readWrite := storage.ContainerAccessPolicy{ID: "read", StartTime: start, ExpiryTime: expiry, CanRead: true, CanWrite: true}
+
+	cases := []struct {
+		Name           string
+		Existing       []storage.ContainerAccessPolicy
+		Desired        []storage.ContainerAccessPolicy
+		ExpectedWrites int
+	}{
+		{
+			Name:           "No Policies",
+			Existing:       []storage.ContainerAccessPolicy{},
+			Desired:        []storage.ContainerAccessPolicy{},
+			ExpectedWrites: 0,
+		},
+		{
+			Name:           "Unchanged",
+			Existing:       []storage.ContainerAccessPolicy{read, write},
+			Desired:        []storage.ContainerAccessPolicy{read, write},
+			ExpectedWrites: 0,
+		},
+		{
+			Name:           "Reordered",
+			Existing:       []storage.ContainerAccessPolicy{write, read},
+			Desired:        []storage.ContainerAccessPolicy{read, write},
+			ExpectedWrites: 0,
+		},
+		{
+			Name:           "Added",
+			Existing:       []storage.ContainerAccessPolicy{read},
+			Desired:        []storage.ContainerAccessPolicy{read, write},
+			ExpectedWrites: 1,
+		},
+		{
+			Name:           "Removed",
+			Existing:       []storage.ContainerAccessPolicy{read, write},
+			Desired:        []storage.ContainerAccessPolicy{read},
+			ExpectedWrites: 1,
+		},
+		{
+			Name:           "Changed",
+			Existing:       []storage.ContainerAccessPolicy{read},
+			Desired:        []storage.ContainerAccessPolicy{readWrite},
+			ExpectedWrites: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Name)
+
+		client := &testStorageContainerRecordingPermissionsClient{
+			existing: storage.ContainerPermissions{
+				AccessType:     storage.ContainerAccessTypeBlob,
+				AccessPolicies: tc.Existing,
+			},
+		}
+		if err := reconcileArmStorageContainerAccessPolicies(client, tc.Desired); err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+
+		if len(client.writes) != tc.ExpectedWrites {
+			t.Fatalf("Expected %d writes but got %d", tc.ExpectedWrites, len(client.writes))
+		}
+
+		for _, write := range client.writes {
+			if write.AccessType != storage.ContainerAccessTypeBlob {
+				t.Fatalf("Expected the access type to be preserved as %q but got %q", storage.ContainerAccessTypeBlob, write.AccessType)
+			}
+		}
+	}
+}
+
+func TestDiffArmStorageContainerAccessPolicies(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiry := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	existing := []storage.ContainerAccessPolicy{
+		{ID: "unchanged", StartTime: start, ExpiryTime: expiry, CanRead: true},
+		{ID: "extended", StartTime: start, ExpiryTime: expiry, CanRead: true},
+		{ID: "removed", StartTime: start, ExpiryTime: expiry, CanDelete: true},
+	}
+	desired := []storage.ContainerAccessPolicy{
+		{ID: "added", StartTime: start, ExpiryTime: expiry, CanWrite: true},
+		{ID: "extended", StartTime: start, ExpiryTime: expiry.AddDate(1, 0, 0), CanRead: true},
+		{ID: "unchanged", StartTime: start.In(time.FixedZone("UTC+1", 3600)), ExpiryTime: expiry, CanRead: true},
+	}
+
+	added, removed, changed := diffArmStorageContainerAccessPolicies(existing, desired)
+	if !reflect.DeepEqual(added, []string{"added"}) {
+		t.Fatalf("Expected the added policies to be %q but got %q", []string{"added"}, added)
+	}
+	if !reflect.DeepEqual(removed, []string{"removed"}) {
+		t.Fatalf("Expected the removed policies to be %q but got %q", []string{"removed"}, removed)
+	}
+	if !reflect.DeepEqual(changed, []string{"extended"}) {
+		t.Fatalf("Expected the changed policies to be %q but got %q", []string{"extended"}, changed)
+	}
+}
+
+func testCheckAzureRMStorageContainerAccessPolicyCount(name string, expected int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+
+		containerName := rs.Primary.Attributes["storage_container_name"]
+		if containerName == "" {
+			containerName = rs.Primary.Attributes["name"]
+		}
+		storageAccountName := rs.Primary.Attributes["storage_account_name"]
+		resourceGroup, hasResourceGroup := rs.Primary.Attributes["resource_group_name"]
+		if !hasResourceGroup {
+			return fmt.Errorf("Bad: no resource group found in state for storage container: %s", containerName)
+		}
+
+		armClient := testAccProvider.Meta().(*ArmClient)
+		ctx := armClient.StopContext
+		blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroup, storageAccountName)
+		if err != nil {
+			return err
+		}
+		if !accountExists {
+			return fmt.Errorf("Bad: Storage Account %q does not exist", storageAccountName)
+		}
+
+		reference := blobClient.GetContainerReference(containerName)
+		exists, err := reference.Exists()
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("Bad: Storage Container %q (storage account: %q) does not exist", containerName, storageAccountName)
+		}
+
+		permissions, err := reference.GetPermissions(&storage.GetContainerPermissionOptions{})
+		if err != nil {
+			return fmt.Errorf("Bad: Get permissions on Storage Container %q (storage account: %q): %+v", containerName, storageAccountName, err)
+		}
+
+		if actual := len(permissions.AccessPolicies); actual != expected {
+			return fmt.Errorf("Bad: expected %d stored access policies on Storage Container %q but got %d", expected, containerName, actual)
+		}
+
+		return nil
+	}
+}
+
+func testCheckAzureRMStorageContainerAccessPolicyDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "azurerm_storage_container_access_policy" {
+			continue
+		}
+
+		containerName := rs.Primary.Attributes["storage_container_name"]
+		storageAccountName := rs.Primary.Attributes["storage_account_name"]
+		resourceGroup := rs.Primary.Attributes["resource_group_name"]
+
+		armClient := testAccProvider.Meta().(*ArmClient)
+		ctx := armClient.StopContext
+		blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroup, storageAccountName)
+		if err != nil {
+			//If we can't get keys then the container can't exist
+			return nil
+		}
+		if !accountExists {
+			return nil
+		}
+
+		reference := blobClient.GetContainerReference(containerName)
+		exists, err := reference.Exists()
+		if err != nil || !exists {
+			return nil
+		}
+
+		permissions, err := reference.GetPermissions(&storage.GetContainerPermissionOptions{})
+		if err != nil {
+			return nil
+		}
+
+		if len(permissions.AccessPolicies) > 0 {
+			return fmt.Errorf("Bad: Storage Container %q (storage account: %q) still has stored access policies", containerName, storageAccountName)
+		}
+	}
+
+	return nil
+}
+
+func testAccAzureRMStorageContainerAccessPolicy_template(rInt int, rString string, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+  name                     = "acctestacc%s"
+  resource_group_name      = "${azurerm_resource_group.test.name}"
+  location                 = "${azurerm_resource_group.test.location}"
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_container" "test" {
+  name                  = "vhds"
+  resource_group_name   = "${azurerm_resource_group.test.name}"
+  storage_account_name  = "${azurerm_storage_account.test.name}"
+  container_access_type = "private"
+}
+`, rInt, location, rString)
+}
+
+func testAccAzureRMStorageContainerAccessPolicy_basic(rInt int, rString string, location string) string {
+	template := testAccAzureRMStorageContainerAccessPolicy_template(rInt, rString, location)
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_storage_container_access_policy" "test" {
+  storage_container_name = "${azurerm_storage_container.test.name}"
+  resource_group_name    = "${azurerm_resource_group.test.name}"
+  storage_account_name   = "${azurerm_storage_account.test.name}"
+
+  stored_access_policy {
+    id          = "read-only"
+    start       = "2018-07-01T00:00:00Z"
+    expiry      = "2028-07-01T00:00:00Z"
+    permissions = "r"
+  }
+}
+`, template)
+}
+
+func testAccAzureRMStorageContainerAccessPolicy_multiple(rInt int, rString string, location string) string {
+	template := testAccAzureRMStorageContainerAccessPolicy_template(rInt, rString, location)
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_storage_container_access_policy" "test" {
+  storage_container_name = "${azurerm_storage_container.test.name}"
+  resource_group_name    = "${azurerm_resource_group.test.name}"
+  storage_account_name   = "${azurerm_storage_account.test.name}"
+
+  stored_access_policy {
+    id          = "read-only"
+    start       = "2018-07-01T00:00:00Z"
+    expiry      = "2028-07-01T00:00:00Z"
+    permissions = "r"
+  }
+
+  stored_access_policy {
+    id          = "read-write"
+    start       = "2018-07-01T00:00:00Z"
+    expiry      = "2019-07-01T00:00:00Z"
+    permissions = "rwd"
+  }
+}
+`, template)
+}
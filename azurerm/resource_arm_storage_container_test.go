@@ -1,14 +1,26 @@
 package azurerm
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/helper/acctest"
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 )
 
@@ -28,6 +40,13 @@ func TestAccAzureRMStorageContainer_basic(t *testing.T) {
 				Config: config,
 				Check: resource.ComposeTestCheckFunc(
 					testCheckAzureRMStorageContainerExists("azurerm_storage_container.test", &c),
+					resource.TestCheckResourceAttr("azurerm_storage_container.test", "account_kind", "Storage"),
+					resource.TestCheckResourceAttr("azurerm_storage_container.test", "account_tier", "Standard"),
+					resource.TestCheckResourceAttrSet("azurerm_storage_container.test", "primary_blob_endpoint"),
+					resource.TestCheckResourceAttr("azurerm_storage_container.test", "secondary_blob_endpoint", ""),
+					resource.TestCheckResourceAttr("azurerm_storage_container.test", "lease_status", "unlocked"),
+					resource.TestCheckResourceAttr("azurerm_storage_container.test", "lease_state", "available"),
+					resource.TestCheckResourceAttr("azurerm_storage_container.test", "lease_duration", ""),
 				),
 			},
 		},
@@ -75,12 +94,269 @@ func TestAccAzureRMStorageContainer_root(t *testing.T) {
 				Check: resource.ComposeTestCheckFunc(
 					testCheckAzureRMStorageContainerExists("azurerm_storage_container.test", &c),
 					resource.TestCheckResourceAttr("azurerm_storage_container.test", "name", "$root"),
+					resource.TestCheckResourceAttr("azurerm_storage_container.test", "properties.lease_status", "unlocked"),
+					resource.TestCheckResourceAttr("azurerm_storage_container.test", "properties.lease_state", "available"),
+					resource.TestCheckResourceAttrSet("azurerm_storage_container.test", "properties.last_modified"),
 				),
 			},
 		},
 	})
 }
 
+func TestAccAzureRMStorageContainer_metadata(t *testing.T) {
+	var c storage.Container
+	resourceName := "azurerm_storage_container.test"
+
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	location := testLocation()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageContainerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMStorageContainer_metadata(ri, rs, location, "hello", false),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerExists(resourceName, &c),
+					resource.TestCheckResourceAttr(resourceName, "metadata.%", "1"),
+					resource.TestCheckResourceAttr(resourceName, "metadata.hello", "world"),
+					resource.TestCheckResourceAttr(resourceName, "last_operation", "created"),
+				),
+			},
+			{
+				Config: testAccAzureRMStorageContainer_metadata(ri, rs, location, "goodbye", false),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerExists(resourceName, &c),
+					resource.TestCheckResourceAttr(resourceName, "metadata.%", "1"),
+					resource.TestCheckResourceAttr(resourceName, "metadata.goodbye", "world"),
+					resource.TestCheckResourceAttr(resourceName, "last_operation", "updated"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageContainer_costTags(t *testing.T) {
+	var c storage.Container
+	resourceName := "azurerm_storage_container.test"
+
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	location := testLocation()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageContainerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMStorageContainer_costTags(ri, rs, location, "engineering"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerExists(resourceName, &c),
+					resource.TestCheckResourceAttr(resourceName, "metadata.%", "1"),
+					resource.TestCheckResourceAttr(resourceName, "metadata.hello", "world"),
+					resource.TestCheckResourceAttr(resourceName, "cost_tags.%", "1"),
+					resource.TestCheckResourceAttr(resourceName, "cost_tags.center", "engineering"),
+				),
+			},
+			{
+				Config: testAccAzureRMStorageContainer_costTags(ri, rs, location, "finance"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerExists(resourceName, &c),
+					resource.TestCheckResourceAttr(resourceName, "metadata.%", "1"),
+					resource.TestCheckResourceAttr(resourceName, "cost_tags.%", "1"),
+					resource.TestCheckResourceAttr(resourceName, "cost_tags.center", "finance"),
+					resource.TestCheckResourceAttr(resourceName, "last_operation", "updated"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageContainer_triggers(t *testing.T) {
+	var c storage.Container
+	resourceName := "azurerm_storage_container.test"
+
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	location := testLocation()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageContainerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMStorageContainer_triggers(ri, rs, location, "first"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerExists(resourceName, &c),
+					resource.TestCheckResourceAttr(resourceName, "triggers.refresh", "first"),
+					resource.TestCheckResourceAttr(resourceName, "last_operation", "created"),
+				),
+			},
+			{
+				Config: testAccAzureRMStorageContainer_triggers(ri, rs, location, "second"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerExists(resourceName, &c),
+					resource.TestCheckResourceAttr(resourceName, "triggers.refresh", "second"),
+					resource.TestCheckResourceAttr(resourceName, "last_operation", "noop"),
+					resource.TestCheckResourceAttr(resourceName, "properties.lease_state", "available"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageContainer_ignoreMetadataChanges(t *testing.T) {
+	var c storage.Container
+	resourceName := "azurerm_storage_container.test"
+
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	config := testAccAzureRMStorageContainer_metadata(ri, rs, testLocation(), "hello", true)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageContainerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerExists(resourceName, &c),
+					testCheckAzureRMStorageContainerSetMetadata(resourceName, map[string]string{
+						"hello":     "world",
+						"managedby": "someone-else",
+					}),
+				),
+			},
+			{
+				// metadata added outside of Terraform shouldn't show up as a diff
+				Config:   config,
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageContainer_createAccountIfMissing(t *testing.T) {
+	var c storage.Container
+	resourceName := "azurerm_storage_container.test"
+
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	config := testAccAzureRMStorageContainer_createAccountIfMissing(ri, rs, testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageContainerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerExists(resourceName, &c),
+					resource.TestCheckResourceAttr(resourceName, "account_created", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageContainer_accessTypeDrift(t *testing.T) {
+	var c storage.Container
+	resourceName := "azurerm_storage_container.test"
+
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	config := testAccAzureRMStorageContainer_basic(ri, rs, testLocation())
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageContainerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerExists(resourceName, &c),
+					resource.TestCheckResourceAttr(resourceName, "container_access_type", "private"),
+					testCheckAzureRMStorageContainerSetAccessType(resourceName, storage.ContainerAccessTypeBlob),
+				),
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				// the access type changed outside of Terraform should be reconciled in-place
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerExists(resourceName, &c),
+					resource.TestCheckResourceAttr(resourceName, "container_access_type", "private"),
+					testCheckAzureRMStorageContainerAccessType(resourceName, "private"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageContainer_accountMove(t *testing.T) {
+	var c storage.Container
+	resourceName := "azurerm_storage_container.test"
+
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	location := testLocation()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageContainerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMStorageContainer_accountMove(ri, rs, location, "first", false),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerExists(resourceName, &c),
+				),
+			},
+			{
+				Config:      testAccAzureRMStorageContainer_accountMove(ri, rs, location, "second", false),
+				ExpectError: regexp.MustCompile("set `allow_account_move` to `true`"),
+			},
+			{
+				Config: testAccAzureRMStorageContainer_accountMove(ri, rs, location, "second", true),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerExists(resourceName, &c),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMStorageContainer_requireEmptyOnAdopt(t *testing.T) {
+	ri := acctest.RandInt()
+	rs := strings.ToLower(acctest.RandString(11))
+	location := testLocation()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMStorageContainerDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAzureRMStorageContainer_template(ri, rs, location),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMStorageContainerCreateWithBlob("azurerm_storage_account.test", "vhds"),
+				),
+			},
+			{
+				Config:      testAccAzureRMStorageContainer_requireEmptyOnAdopt(ri, rs, location),
+				ExpectError: regexp.MustCompile("The container isn't empty"),
+			},
+		},
+	})
+}
+
 func testCheckAzureRMStorageContainerExists(name string, c *storage.Container) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 
@@ -167,6 +443,119 @@ func testAccARMStorageContainerDisappears(name string, c *storage.Container) res
 	}
 }
 
+// testCheckAzureRMStorageContainerCreateWithBlob creates a container containing a blob outside of Terraform
+func testCheckAzureRMStorageContainerCreateWithBlob(accountResourceName, containerName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[accountResourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", accountResourceName)
+		}
+
+		storageAccountName := rs.Primary.Attributes["name"]
+		resourceGroup := rs.Primary.Attributes["resource_group_name"]
+
+		armClient := testAccProvider.Meta().(*ArmClient)
+		ctx := armClient.StopContext
+		blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroup, storageAccountName)
+		if err != nil {
+			return err
+		}
+		if !accountExists {
+			return fmt.Errorf("Bad: Storage Account %q does not exist", storageAccountName)
+		}
+
+		reference := blobClient.GetContainerReference(containerName)
+		if err := reference.Create(&storage.CreateContainerOptions{}); err != nil {
+			return fmt.Errorf("Error creating container %q: %+v", containerName, err)
+		}
+
+		blob := reference.GetBlobReference("existing.txt")
+		if err := blob.CreateBlockBlobFromReader(strings.NewReader("hello"), &storage.PutBlobOptions{}); err != nil {
+			return fmt.Errorf("Error creating blob in container %q: %+v", containerName, err)
+		}
+
+		return nil
+	}
+}
+
+func testCheckAzureRMStorageContainerSetMetadata(name string, metadata map[string]string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		reference, err := testGetAzureRMStorageContainerReference(s, name)
+		if err != nil {
+			return err
+		}
+
+		reference.Metadata = metadata
+		if err := reference.SetMetadata(&storage.ContainerMetadataOptions{}); err != nil {
+			return fmt.Errorf("Bad: Set metadata on Storage Container %q: %+v", reference.Name, err)
+		}
+
+		return nil
+	}
+}
+
+func testCheckAzureRMStorageContainerSetAccessType(name string, accessType storage.ContainerAccessType) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		reference, err := testGetAzureRMStorageContainerReference(s, name)
+		if err != nil {
+			return err
+		}
+
+		permissions := storage.ContainerPermissions{
+			AccessType: accessType,
+		}
+		if err := reference.SetPermissions(permissions, &storage.SetContainerPermissionOptions{}); err != nil {
+			return fmt.Errorf("Bad: Set permissions on Storage Container %q: %+v", reference.Name, err)
+		}
+
+		return nil
+	}
+}
+
+func testCheckAzureRMStorageContainerAccessType(name string, expected string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		reference, err := testGetAzureRMStorageContainerReference(s, name)
+		if err != nil {
+			return err
+		}
+
+		permissions, err := reference.GetPermissions(&storage.GetContainerPermissionOptions{})
+		actual, err := storageContainerAccessTypeFromPermissions(permissions, err)
+		if err != nil {
+			return fmt.Errorf("Bad: Get permissions on Storage Container %q: %+v", reference.Name, err)
+		}
+
+		if actual != expected {
+			return fmt.Errorf("Bad: expected Storage Container %q to have an access type of %q but got %q", reference.Name, expected, actual)
+		}
+
+		return nil
+	}
+}
+
+func testGetAzureRMStorageContainerReference(s *terraform.State, name string) (*storage.Container, error) {
+	rs, ok := s.RootModule().Resources[name]
+	if !ok {
+		return nil, fmt.Errorf("Not found: %s", name)
+	}
+
+	containerName := rs.Primary.Attributes["name"]
+	storageAccountName := rs.Primary.Attributes["storage_account_name"]
+	resourceGroup := rs.Primary.Attributes["resource_group_name"]
+
+	armClient := testAccProvider.Meta().(*ArmClient)
+	ctx := armClient.StopContext
+	blobClient, accountExists, err := armClient.getBlobStorageClientForStorageAccount(ctx, resourceGroup, storageAccountName)
+	if err != nil {
+		return nil, err
+	}
+	if !accountExists {
+		return nil, fmt.Errorf("Bad: Storage Account %q does not exist", storageAccountName)
+	}
+
+	return blobClient.GetContainerReference(containerName), nil
+}
+
 func testCheckAzureRMStorageContainerDestroy(s *terraform.State) error {
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "azurerm_storage_container" {
@@ -220,6 +609,9 @@ func TestValidateArmStorageContainerName(t *testing.T) {
 		"valid-name",
 		"valid02-name",
 		"$root",
+		"$web",
+		"$logs",
+		"$blobchangefeed",
 	}
 	for _, v := range validNames {
 		_, errors := validateArmStorageContainerName(v, "name")
@@ -231,10 +623,14 @@ func TestValidateArmStorageContainerName(t *testing.T) {
 	invalidNames := []string{
 		"InvalidName1",
 		"-invalidname1",
+		"invalid--name",
 		"invalid_name",
 		"invalid!",
 		"ww",
 		"$notroot",
+		"$foo",
+		"$ROOT",
+		"$web-backup",
 		strings.Repeat("w", 65),
 	}
 	for _, v := range invalidNames {
@@ -245,18 +641,1774 @@ func TestValidateArmStorageContainerName(t *testing.T) {
 	}
 }
 
-func testAccAzureRMStorageContainer_basic(rInt int, rString string, location string) string {
-	return fmt.Sprintf(`
-resource "azurerm_resource_group" "test" {
-    name = "acctestRG-%d"
-    location = "%s"
+func TestValidateArmStorageContainerMetadata(t *testing.T) {
+	cases := []struct {
+		Key    string
+		Errors int
+	}{
+		{Key: "hello", Errors: 0},
+		{Key: "_hello", Errors: 0},
+		{Key: "hello_world2", Errors: 0},
+		{Key: "Hello", Errors: 1},
+		{Key: "2hello", Errors: 1},
+		{Key: "hello-world", Errors: 1},
+		{Key: "", Errors: 1},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Key)
+		_, errors := validateArmStorageContainerMetadata(map[string]interface{}{tc.Key: "value"}, "metadata")
+		if len(errors) != tc.Errors {
+			t.Fatalf("Expected %d errors for key %q but got %d: %+v", tc.Errors, tc.Key, len(errors), errors)
+		}
+	}
 }
 
-resource "azurerm_storage_account" "test" {
-    name                     = "acctestacc%s"
-    resource_group_name      = "${azurerm_resource_group.test.name}"
-    location                 = "${azurerm_resource_group.test.location}"
-    account_tier             = "Standard"
+func TestValidateArmStorageContainerUserMetadata(t *testing.T) {
+	cases := []struct {
+		Key    string
+		Errors int
+	}{
+		{Key: "hello", Errors: 0},
+		{Key: "costing", Errors: 0},
+		{Key: "cost_center", Errors: 1},
+		{Key: "Cost_center", Errors: 1},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Key)
+		_, errors := validateArmStorageContainerUserMetadata(map[string]interface{}{tc.Key: "value"}, "metadata")
+		if len(errors) != tc.Errors {
+			t.Fatalf("Expected %d errors for key %q but got %d: %+v", tc.Errors, tc.Key, len(errors), errors)
+		}
+	}
+}
+
+func TestArmStorageContainerMetadataWithCostTags(t *testing.T) {
+	metadata := map[string]string{
+		"hello":      "world",
+		"cost_stale": "removed",
+	}
+	costTags := map[string]interface{}{
+		"center": "engineering",
+		"owner":  "platform",
+	}
+
+	expanded := expandArmStorageContainerMetadataWithCostTags(metadata, costTags)
+	expected := map[string]string{
+		"hello":       "world",
+		"cost_center": "engineering",
+		"cost_owner":  "platform",
+	}
+	if !reflect.DeepEqual(expanded, expected) {
+		t.Fatalf("Expected the expanded metadata to be %+v but got %+v", expected, expanded)
+	}
+
+	flattenedMetadata, flattenedCostTags := flattenArmStorageContainerMetadataAndCostTags(expanded)
+	if !reflect.DeepEqual(flattenedMetadata, map[string]interface{}{"hello": "world"}) {
+		t.Fatalf("Expected the flattened metadata to only contain `hello` but got %+v", flattenedMetadata)
+	}
+	if !reflect.DeepEqual(flattenedCostTags, costTags) {
+		t.Fatalf("Expected the flattened cost tags to be %+v but got %+v", costTags, flattenedCostTags)
+	}
+}
+
+func TestMergeArmStorageContainerMetadata(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Existing map[string]string
+		Old      map[string]interface{}
+		New      map[string]interface{}
+		Expected map[string]string
+	}{
+		{
+			Name:     "Configured Value Changed",
+			Existing: map[string]string{"hello": "world", "external": "value", "cost_center": "engineering"},
+			Old:      map[string]interface{}{"hello": "world"},
+			New:      map[string]interface{}{"hello": "terraform"},
+			Expected: map[string]string{"hello": "terraform", "external": "value", "cost_center": "engineering"},
+		},
+		{
+			Name:     "Configured Value Added",
+			Existing: map[string]string{"external": "value"},
+			Old:      map[string]interface{}{},
+			New:      map[string]interface{}{"hello": "world"},
+			Expected: map[string]string{"hello": "world", "external": "value"},
+		},
+		{
+			Name:     "Configured Value Removed",
+			Existing: map[string]string{"hello": "world", "external": "value"},
+			Old:      map[string]interface{}{"hello": "world"},
+			New:      map[string]interface{}{},
+			Expected: map[string]string{"external": "value"},
+		},
+	}
+
+	for _, v := range cases {
+		t.Logf("[DEBUG] Testing %q", v.Name)
+
+		actual := mergeArmStorageContainerMetadata(v.Existing, v.Old, v.New)
+		if !reflect.DeepEqual(actual, v.Expected) {
+			t.Fatalf("Expected the metadata to be %+v but got %+v", v.Expected, actual)
+		}
+	}
+}
+
+type testStorageRecordingSender struct {
+	request *http.Request
+}
+
+func (s *testStorageRecordingSender) Send(c *storage.Client, req *http.Request) (*http.Response, error) {
+	s.request = req
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestArmClientStorageDataPlaneAPIVersion(t *testing.T) {
+	client := &ArmClient{}
+	if actual := client.storageDataPlaneAPIVersion(); actual != storage.DefaultAPIVersion {
+		t.Fatalf("Expected the default API Version %q but got %q", storage.DefaultAPIVersion, actual)
+	}
+
+	client.storageAPIVersion = "2017-07-29"
+	if actual := client.storageDataPlaneAPIVersion(); actual != "2017-07-29" {
+		t.Fatalf("Expected the overridden API Version %q but got %q", "2017-07-29", actual)
+	}
+}
+
+func TestStorageEndpointOverrideSender(t *testing.T) {
+	inner := &testStorageRecordingSender{}
+	sender, err := newStorageEndpointOverrideSender("https://example.privatelink.blob.core.windows.net", inner)
+	if err != nil {
+		t.Fatalf("Error building sender: %+v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.blob.core.windows.net/vhds?restype=container", nil)
+	if err != nil {
+		t.Fatalf("Error building request: %+v", err)
+	}
+
+	if _, err := sender.Send(nil, req); err != nil {
+		t.Fatalf("Error sending request: %+v", err)
+	}
+
+	expected := "https://example.privatelink.blob.core.windows.net/vhds?restype=container"
+	if actual := inner.request.URL.String(); actual != expected {
+		t.Fatalf("Expected the request to be sent to %q but got %q", expected, actual)
+	}
+	if inner.request.Host != "example.privatelink.blob.core.windows.net" {
+		t.Fatalf("Expected the Host header to be overridden but got %q", inner.request.Host)
+	}
+
+	invalidEndpoints := []string{
+		"example.privatelink.blob.core.windows.net",
+		"/vhds",
+	}
+	for _, v := range invalidEndpoints {
+		if _, err := newStorageEndpointOverrideSender(v, inner); err == nil {
+			t.Fatalf("Expected an error for the endpoint %q but didn't get one", v)
+		}
+	}
+}
+
+func TestParseStorageContainerID(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected *storageContainerID
+	}{
+		{
+			Input:    "",
+			Expected: nil,
+		},
+		{
+			Input:    "vhds",
+			Expected: nil,
+		},
+		{
+			Input:    "https://example.blob.core.windows.net",
+			Expected: nil,
+		},
+		{
+			Input:    "https://example.file.core.windows.net/vhds",
+			Expected: nil,
+		},
+		{
+			Input:    "https://example.blob.core.windows.net/vhds/blob.vhd",
+			Expected: nil,
+		},
+		{
+			Input: "https://example.blob.core.windows.net/vhds",
+			Expected: &storageContainerID{
+				storageAccountName: "example",
+				containerName:      "vhds",
+				endpointSuffix:     "core.windows.net",
+			},
+		},
+		{
+			Input: "https://example.blob.core.chinacloudapi.cn/$root",
+			Expected: &storageContainerID{
+				storageAccountName: "example",
+				containerName:      "$root",
+				endpointSuffix:     "core.chinacloudapi.cn",
+			},
+		},
+		{
+			// the path is decoded when the URL is parsed
+			Input: "https://example.blob.core.windows.net/%24root",
+			Expected: &storageContainerID{
+				storageAccountName: "example",
+				containerName:      "$root",
+				endpointSuffix:     "core.windows.net",
+			},
+		},
+		{
+			// and is only decoded once
+			Input: "https://example.blob.core.windows.net/%2524root",
+			Expected: &storageContainerID{
+				storageAccountName: "example",
+				containerName:      "%24root",
+				endpointSuffix:     "core.windows.net",
+			},
+		},
+		{
+			// an encoded separator still results in a nested path
+			Input:    "https://example.blob.core.windows.net/vhds%2Fblob.vhd",
+			Expected: nil,
+		},
+		{
+			Input:    "https://example.blob.core.windows.net/%zzvhds",
+			Expected: nil,
+		},
+	}
+
+	for _, v := range cases {
+		t.Logf("[DEBUG] Testing %q", v.Input)
+
+		actual, err := parseStorageContainerID(v.Input)
+		if v.Expected == nil {
+			if err == nil {
+				t.Fatalf("Expected an error but didn't get one")
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Error parsing: %+v", err)
+		}
+
+		if *actual != *v.Expected {
+			t.Fatalf("Expected %+v but got %+v", *v.Expected, *actual)
+		}
+	}
+}
+
+type testStorageCountingSender struct {
+	statusCode int
+	requests   []string
+}
+
+func (s *testStorageCountingSender) Send(c *storage.Client, req *http.Request) (*http.Response, error) {
+	s.requests = append(s.requests, fmt.Sprintf("%s %s", req.Method, req.URL.RawQuery))
+
+	header := http.Header{}
+	header.Set("x-ms-lease-state", "available")
+	header.Set("x-ms-meta-hello", "world")
+	return &http.Response{
+		StatusCode: s.statusCode,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+type testStorageSequenceSender struct {
+	statusCodes []int
+	requests    int
+}
+
+func (s *testStorageSequenceSender) Send(c *storage.Client, req *http.Request) (*http.Response, error) {
+	// the last status code is repeated for any further requests
+	statusCode := s.statusCodes[len(s.statusCodes)-1]
+	if s.requests < len(s.statusCodes) {
+		statusCode = s.statusCodes[s.requests]
+	}
+	s.requests++
+
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+func TestWaitForStorageContainerDeletionRetryFunc(t *testing.T) {
+	cases := []struct {
+		Name             string
+		StatusCodes      []int
+		ExpectedRequests int
+		ExpectError      bool
+	}{
+		{
+			Name:             "Already Gone",
+			StatusCodes:      []int{http.StatusNotFound},
+			ExpectedRequests: 1,
+		},
+		{
+			Name:             "Delayed Disappearance",
+			StatusCodes:      []int{http.StatusOK, http.StatusOK, http.StatusNotFound},
+			ExpectedRequests: 3,
+		},
+		{
+			Name:             "Forbidden",
+			StatusCodes:      []int{http.StatusForbidden},
+			ExpectedRequests: 1,
+			ExpectError:      true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Name)
+
+		client, err := storage.NewBasicClient("acctestaccount", "YWNjZXNza2V5")
+		if err != nil {
+			t.Fatalf("Error building storage client: %+v", err)
+		}
+		sender := &testStorageSequenceSender{statusCodes: tc.StatusCodes}
+		client.Sender = sender
+
+		blobClient := client.GetBlobService()
+		reference := blobClient.GetContainerReference("vhds")
+		err = resource.Retry(10*time.Second, waitForStorageContainerDeletionRetryFunc(reference))
+		if tc.ExpectError && err == nil {
+			t.Fatalf("Expected an error but didn't get one")
+		}
+		if !tc.ExpectError && err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+
+		if sender.requests != tc.ExpectedRequests {
+			t.Fatalf("Expected %d requests but got %d", tc.ExpectedRequests, sender.requests)
+		}
+	}
+}
+
+func TestExpandArmStorageContainerInitialBlobs(t *testing.T) {
+	blob := func(name, source, sourceContent string) interface{} {
+		return map[string]interface{}{
+			"name":           name,
+			"source":         source,
+			"source_content": sourceContent,
+			"content_type":   "text/plain",
+		}
+	}
+
+	cases := []struct {
+		Name          string
+		Input         []interface{}
+		ExpectedCount int
+		ExpectError   bool
+	}{
+		{
+			Name:          "None",
+			Input:         []interface{}{},
+			ExpectedCount: 0,
+		},
+		{
+			Name:          "Source And Source Content",
+			Input:         []interface{}{blob("a.txt", "/tmp/a.txt", ""), blob("b.txt", "", "hello")},
+			ExpectedCount: 2,
+		},
+		{
+			Name:        "Neither Source Nor Source Content",
+			Input:       []interface{}{blob("a.txt", "", "")},
+			ExpectError: true,
+		},
+		{
+			Name:        "Both Source And Source Content",
+			Input:       []interface{}{blob("a.txt", "/tmp/a.txt", "hello")},
+			ExpectError: true,
+		},
+		{
+			Name:        "Duplicate Names",
+			Input:       []interface{}{blob("a.txt", "", "hello"), blob("a.txt", "", "world")},
+			ExpectError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Name)
+
+		blobs, err := expandArmStorageContainerInitialBlobs(tc.Input)
+		if tc.ExpectError {
+			if err == nil {
+				t.Fatalf("Expected an error but didn't get one")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected no error but got: %s", err)
+		}
+
+		if len(blobs) != tc.ExpectedCount {
+			t.Fatalf("Expected %d blobs but got %d", tc.ExpectedCount, len(blobs))
+		}
+		for _, b := range blobs {
+			if b.contentType != "text/plain" {
+				t.Fatalf("Expected the content type of %q to be %q but got %q", b.name, "text/plain", b.contentType)
+			}
+		}
+	}
+}
+
+func TestStorageContainerCreateErrorIsRetryable(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Error    error
+		Expected bool
+	}{
+		{
+			Name:     "Timeout",
+			Error:    &url.Error{Op: "Put", URL: "https://acctestaccount.blob.core.windows.net/vhds", Err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}},
+			Expected: true,
+		},
+		{
+			Name:     "Temporary DNS Failure",
+			Error:    &url.Error{Op: "Put", URL: "https://acctestaccount.blob.core.windows.net/vhds", Err: &net.DNSError{Err: "server misbehaving", IsTemporary: true}},
+			Expected: true,
+		},
+		{
+			Name:     "Unknown Host",
+			Error:    &url.Error{Op: "Put", URL: "https://acctestaccount.example.com/vhds", Err: &net.DNSError{Err: "no such host", Name: "acctestaccount.example.com", IsNotFound: true}},
+			Expected: false,
+		},
+		{
+			Name:     "TLS Error",
+			Error:    &url.Error{Op: "Put", URL: "https://acctestaccount.blob.core.windows.net/vhds", Err: fmt.Errorf("x509: certificate is valid for example.com, not acctestaccount.blob.core.windows.net")},
+			Expected: false,
+		},
+		{
+			Name:     "Invalid Credentials",
+			Error:    fmt.Errorf("illegal base64 data at input byte 4"),
+			Expected: false,
+		},
+		{
+			Name:     "Account Read-Only During Maintenance",
+			Error:    storage.AzureStorageServiceError{StatusCode: http.StatusServiceUnavailable, Code: "ServerBusy"},
+			Expected: true,
+		},
+		{
+			Name:     "Account Being Created",
+			Error:    storage.AzureStorageServiceError{StatusCode: http.StatusConflict, Code: "AccountBeingCreated"},
+			Expected: true,
+		},
+		{
+			Name:     "Container Being Deleted",
+			Error:    storage.AzureStorageServiceError{StatusCode: http.StatusConflict, Code: "ContainerBeingDeleted"},
+			Expected: true,
+		},
+		{
+			Name:     "Throttled",
+			Error:    storage.AzureStorageServiceError{StatusCode: http.StatusTooManyRequests},
+			Expected: true,
+		},
+		{
+			Name:     "Read-Only Endpoint",
+			Error:    storage.AzureStorageServiceError{StatusCode: http.StatusForbidden, Code: "InsufficientAccountPermissions"},
+			Expected: false,
+		},
+		{
+			Name:     "Account Disabled",
+			Error:    storage.AzureStorageServiceError{StatusCode: http.StatusForbidden, Code: "AccountIsDisabled"},
+			Expected: false,
+		},
+		{
+			Name:     "Invalid Name",
+			Error:    storage.AzureStorageServiceError{StatusCode: http.StatusBadRequest, Code: "InvalidResourceName"},
+			Expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Name)
+
+		if actual := storageContainerCreateErrorIsRetryable(tc.Error); actual != tc.Expected {
+			t.Fatalf("Expected retryable to be %t but got %t", tc.Expected, actual)
+		}
+	}
+}
+
+func TestCheckContainerIsCreated_transientReadOnly(t *testing.T) {
+	client, err := storage.NewBasicClient("acctestaccount", "YWNjZXNza2V5")
+	if err != nil {
+		t.Fatalf("Error building storage client: %+v", err)
+	}
+	sender := &testStorageSequenceSender{statusCodes: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusCreated}}
+	client.Sender = sender
+
+	blobClient := client.GetBlobService()
+	reference := blobClient.GetContainerReference("vhds")
+
+	var created bool
+	if err := retryStorageContainerCreate(10*time.Second, 10*time.Millisecond, checkContainerIsCreated(reference, &created)); err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+	if !created {
+		t.Fatalf("Expected the container to have been created")
+	}
+	if sender.requests != 3 {
+		t.Fatalf("Expected 3 requests but got %d", sender.requests)
+	}
+}
+
+func TestDeleteStorageContainerRetryFunc(t *testing.T) {
+	cases := []struct {
+		Name             string
+		StatusCodes      []int
+		ExpectedRequests int
+		ExpectError      bool
+	}{
+		{
+			Name:             "Deleted",
+			StatusCodes:      []int{http.StatusAccepted},
+			ExpectedRequests: 1,
+		},
+		{
+			Name:             "Not Found",
+			StatusCodes:      []int{http.StatusNotFound},
+			ExpectedRequests: 1,
+		},
+		{
+			Name:             "Transient Server Error",
+			StatusCodes:      []int{http.StatusInternalServerError, http.StatusAccepted},
+			ExpectedRequests: 2,
+		},
+		{
+			Name:             "Throttled",
+			StatusCodes:      []int{http.StatusTooManyRequests, http.StatusAccepted},
+			ExpectedRequests: 2,
+		},
+		{
+			Name:             "Forbidden",
+			StatusCodes:      []int{http.StatusForbidden},
+			ExpectedRequests: 1,
+			ExpectError:      true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Name)
+
+		client, err := storage.NewBasicClient("acctestaccount", "YWNjZXNza2V5")
+		if err != nil {
+			t.Fatalf("Error building storage client: %+v", err)
+		}
+		sender := &testStorageSequenceSender{statusCodes: tc.StatusCodes}
+		client.Sender = sender
+
+		blobClient := client.GetBlobService()
+		reference := blobClient.GetContainerReference("vhds")
+		err = resource.Retry(10*time.Second, deleteStorageContainerRetryFunc(reference))
+		if tc.ExpectError && err == nil {
+			t.Fatalf("Expected an error but didn't get one")
+		}
+		if !tc.ExpectError && err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+
+		if sender.requests != tc.ExpectedRequests {
+			t.Fatalf("Expected %d requests but got %d", tc.ExpectedRequests, sender.requests)
+		}
+	}
+}
+
+type testStorageContainerBlobsLister struct {
+	blobs []storage.Blob
+}
+
+func (l testStorageContainerBlobsLister) ListBlobs(params storage.ListBlobsParameters) (storage.BlobListResponse, error) {
+	return storage.BlobListResponse{
+		Blobs: l.blobs,
+	}, nil
+}
+
+func TestCheckStorageContainerAnonymousAccess(t *testing.T) {
+	cases := []struct {
+		AccessType        string
+		Blobs             []storage.Blob
+		StatusCode        int
+		ExpectedMethod    string
+		ExpectedPath      string
+		ExpectedEffective bool
+	}{
+		{
+			AccessType: "private",
+		},
+		{
+			AccessType:        "container",
+			StatusCode:        http.StatusOK,
+			ExpectedMethod:    http.MethodGet,
+			ExpectedPath:      "/vhds",
+			ExpectedEffective: true,
+		},
+		{
+			AccessType:     "container",
+			StatusCode:     http.StatusForbidden,
+			ExpectedMethod: http.MethodGet,
+			ExpectedPath:   "/vhds",
+		},
+		{
+			AccessType: "blob",
+		},
+		{
+			AccessType:        "blob",
+			Blobs:             []storage.Blob{{Name: "some file.vhd"}},
+			StatusCode:        http.StatusOK,
+			ExpectedMethod:    http.MethodHead,
+			ExpectedPath:      "/vhds/some file.vhd",
+			ExpectedEffective: true,
+		},
+		{
+			AccessType:     "blob",
+			Blobs:          []storage.Blob{{Name: "some file.vhd"}},
+			StatusCode:     http.StatusNotFound,
+			ExpectedMethod: http.MethodHead,
+			ExpectedPath:   "/vhds/some file.vhd",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q with %d blobs and a %d response", tc.AccessType, len(tc.Blobs), tc.StatusCode)
+
+		var requests []*http.Request
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			w.WriteHeader(tc.StatusCode)
+		}))
+
+		lister := testStorageContainerBlobsLister{blobs: tc.Blobs}
+		effective, err := checkStorageContainerAnonymousAccess(server.Client(), lister, server.URL+"/vhds", tc.AccessType)
+		server.Close()
+		if err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+
+		if effective != tc.ExpectedEffective {
+			t.Fatalf("Expected anonymous access to be %t but got %t", tc.ExpectedEffective, effective)
+		}
+
+		if tc.ExpectedMethod == "" {
+			if len(requests) != 0 {
+				t.Fatalf("Expected no requests but got %d", len(requests))
+			}
+			continue
+		}
+
+		if len(requests) != 1 {
+			t.Fatalf("Expected 1 request but got %d", len(requests))
+		}
+		req := requests[0]
+		if req.Method != tc.ExpectedMethod || req.URL.Path != tc.ExpectedPath {
+			t.Fatalf("Expected a %s request to %q but got a %s request to %q", tc.ExpectedMethod, tc.ExpectedPath, req.Method, req.URL.Path)
+		}
+		if auth := req.Header.Get("Authorization"); auth != "" {
+			t.Fatalf("Expected the request to be anonymous but got the Authorization header %q", auth)
+		}
+	}
+}
+
+func TestCheckStorageContainerAnonymousAccess_customEndpoint(t *testing.T) {
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	containerURL, err := storageContainerURLForEndpoint("https://acctestanonymous.blob.core.windows.net/vhds", server.URL)
+	if err != nil {
+		t.Fatalf("Error building the container URL: %+v", err)
+	}
+
+	effective, err := checkStorageContainerAnonymousAccess(server.Client(), testStorageContainerBlobsLister{}, containerURL, "container")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+	if !effective {
+		t.Fatalf("Expected anonymous access to be effective via the custom endpoint")
+	}
+	if len(requests) != 1 || requests[0].URL.Path != "/vhds" {
+		t.Fatalf("Expected a single request to the container on the custom endpoint but got %d requests", len(requests))
+	}
+}
+
+func TestStorageContainerURLForEndpoint(t *testing.T) {
+	cases := []struct {
+		Name        string
+		Endpoint    string
+		Expected    string
+		ExpectError bool
+	}{
+		{
+			Name:     "No Endpoint",
+			Endpoint: "",
+			Expected: "https://example.blob.core.windows.net/vhds",
+		},
+		{
+			Name:     "Custom Endpoint",
+			Endpoint: "https://example.privatelink.blob.core.windows.net",
+			Expected: "https://example.privatelink.blob.core.windows.net/vhds",
+		},
+		{
+			Name:     "Custom Endpoint with a different Scheme and Port",
+			Endpoint: "http://10.0.0.4:10000",
+			Expected: "http://10.0.0.4:10000/vhds",
+		},
+		{
+			Name:        "Relative Endpoint",
+			Endpoint:    "example.privatelink.blob.core.windows.net",
+			ExpectError: true,
+		},
+	}
+
+	for _, v := range cases {
+		t.Logf("[DEBUG] Testing %q", v.Name)
+
+		actual, err := storageContainerURLForEndpoint("https://example.blob.core.windows.net/vhds", v.Endpoint)
+		if v.ExpectError {
+			if err == nil {
+				t.Fatalf("Expected an error but didn't get one")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+		if actual != v.Expected {
+			t.Fatalf("Expected the URL to be %q but got %q", v.Expected, actual)
+		}
+	}
+}
+
+func TestCheckStorageContainerAnonymousAccess_unreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	containerURL := server.URL + "/vhds"
+	server.Close()
+
+	effective, err := checkStorageContainerAnonymousAccess(server.Client(), testStorageContainerBlobsLister{}, containerURL, "container")
+	if err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+	if effective {
+		t.Fatalf("Expected an unreachable container not to be accessible anonymously")
+	}
+}
+
+func TestDescribeStorageError(t *testing.T) {
+	cases := []struct {
+		Name         string
+		Error        error
+		ExpectedHint string
+	}{
+		{
+			Name:         "AuthorizationFailure",
+			Error:        storage.AzureStorageServiceError{StatusCode: http.StatusForbidden, Code: "AuthorizationFailure"},
+			ExpectedHint: "`network_rules`",
+		},
+		{
+			Name:         "ContainerBeingDeleted",
+			Error:        storage.AzureStorageServiceError{StatusCode: http.StatusConflict, Code: "ContainerBeingDeleted"},
+			ExpectedHint: "still being removed",
+		},
+		{
+			Name:         "InvalidResourceName",
+			Error:        storage.AzureStorageServiceError{StatusCode: http.StatusBadRequest, Code: "InvalidResourceName"},
+			ExpectedHint: "3-63 characters",
+		},
+		{
+			Name:         "ResourceNotFound",
+			Error:        storage.AzureStorageServiceError{StatusCode: http.StatusNotFound, Code: "ResourceNotFound"},
+			ExpectedHint: "`storage_account_name` is correct",
+		},
+		{
+			Name:         "Forbidden HEAD",
+			Error:        storage.AzureStorageServiceError{StatusCode: http.StatusForbidden},
+			ExpectedHint: "`network_rules`",
+		},
+		{
+			Name:         "Not Found HEAD",
+			Error:        storage.AzureStorageServiceError{StatusCode: http.StatusNotFound},
+			ExpectedHint: "`storage_account_name` is correct",
+		},
+		{
+			Name:  "Unknown Code",
+			Error: storage.AzureStorageServiceError{StatusCode: http.StatusForbidden, Code: "AuthenticationFailed"},
+		},
+		{
+			Name:  "Not a Storage Error",
+			Error: fmt.Errorf("connection reset by peer"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Name)
+
+		actual := describeStorageError(tc.Error)
+		if !strings.HasPrefix(actual, tc.Error.Error()) {
+			t.Fatalf("Expected %q to begin with the original error %q", actual, tc.Error.Error())
+		}
+
+		if tc.ExpectedHint == "" {
+			if actual != tc.Error.Error() {
+				t.Fatalf("Expected no hint but got %q", actual)
+			}
+			continue
+		}
+
+		if !strings.Contains(actual, tc.ExpectedHint) {
+			t.Fatalf("Expected %q to contain the hint %q", actual, tc.ExpectedHint)
+		}
+	}
+}
+
+func TestArmClientStorageContainerNotFound(t *testing.T) {
+	cases := []struct {
+		StrictRead  bool
+		ExpectError bool
+		ExpectedID  string
+	}{
+		{
+			StrictRead: false,
+			ExpectedID: "",
+		},
+		{
+			StrictRead:  true,
+			ExpectError: true,
+			ExpectedID:  "vhds",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing with strict reads %t", tc.StrictRead)
+
+		d := schema.TestResourceDataRaw(t, resourceArmStorageContainer().Schema, map[string]interface{}{
+			"name": "vhds",
+		})
+		d.SetId("vhds")
+
+		client := &ArmClient{storageStrictRead: tc.StrictRead}
+		err := client.storageContainerNotFound(d, "Storage container \"vhds\" was not found")
+		if tc.ExpectError && err == nil {
+			t.Fatalf("Expected an error but didn't get one")
+		}
+		if !tc.ExpectError && err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+
+		if d.Id() != tc.ExpectedID {
+			t.Fatalf("Expected the ID to be %q but got %q", tc.ExpectedID, d.Id())
+		}
+	}
+}
+
+func TestRetryStorageContainerCreate(t *testing.T) {
+	pollInterval := 50 * time.Millisecond
+
+	var attempts []time.Time
+	err := retryStorageContainerCreate(5*time.Second, pollInterval, func() *resource.RetryError {
+		attempts = append(attempts, time.Now())
+		if len(attempts) < 3 {
+			return resource.RetryableError(fmt.Errorf("The specified container is being deleted. Try operation later."))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+
+	if len(attempts) != 3 {
+		t.Fatalf("Expected 3 attempts but got %d", len(attempts))
+	}
+
+	for i := 1; i < len(attempts); i++ {
+		if elapsed := attempts[i].Sub(attempts[i-1]); elapsed < pollInterval {
+			t.Fatalf("Expected attempt %d to be at least %s after the previous attempt but it was %s", i+1, pollInterval, elapsed)
+		}
+	}
+}
+
+func TestRetryStorageContainerCreate_nonRetryableError(t *testing.T) {
+	attempts := 0
+	err := retryStorageContainerCreate(5*time.Second, 10*time.Millisecond, func() *resource.RetryError {
+		attempts++
+		return resource.NonRetryableError(fmt.Errorf("The specified resource name contains invalid characters."))
+	})
+	if err == nil {
+		t.Fatalf("Expected an error but didn't get one")
+	}
+
+	if attempts != 1 {
+		t.Fatalf("Expected 1 attempt but got %d", attempts)
+	}
+}
+
+func TestArmClientRetrieveStorageContainerUsingCache(t *testing.T) {
+	client, err := storage.NewBasicClient("acctestaccount", "YWNjZXNza2V5")
+	if err != nil {
+		t.Fatalf("Error building storage client: %+v", err)
+	}
+	sender := &testStorageCountingSender{statusCode: http.StatusOK}
+	client.Sender = sender
+	blobClient := client.GetBlobService()
+
+	armClient := &ArmClient{}
+
+	// the Exists check retrieves the container, which the Read then uses rather than retrieving it again
+	exists, err := armClient.retrieveStorageContainerForCache("acctestaccount", blobClient.GetContainerReference("vhds"))
+	if err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+	if !exists {
+		t.Fatalf("Expected the container to exist")
+	}
+	if len(sender.requests) != 2 {
+		t.Fatalf("Expected the Exists check to make 2 requests but got %d: %+v", len(sender.requests), sender.requests)
+	}
+
+	reference := blobClient.GetContainerReference("vhds")
+	exists, err = armClient.retrieveStorageContainerUsingCache("acctestaccount", reference)
+	if err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+	if !exists {
+		t.Fatalf("Expected the container to exist")
+	}
+	if len(sender.requests) != 2 {
+		t.Fatalf("Expected the Read not to make any requests but got %d: %+v", len(sender.requests)-2, sender.requests[2:])
+	}
+	if reference.Properties.LeaseState != "available" {
+		t.Fatalf("Expected the lease state to be populated from the cache but got %q", reference.Properties.LeaseState)
+	}
+	if reference.Metadata["hello"] != "world" {
+		t.Fatalf("Expected the metadata to be populated from the cache but got %+v", reference.Metadata)
+	}
+
+	// cached values are only used once, so a subsequent Read retrieves the container
+	if _, err := armClient.retrieveStorageContainerUsingCache("acctestaccount", blobClient.GetContainerReference("vhds")); err != nil {
+		t.Fatalf("Expected no error but got: %+v", err)
+	}
+	if len(sender.requests) != 4 {
+		t.Fatalf("Expected a subsequent Read to make 2 requests but got %d: %+v", len(sender.requests)-2, sender.requests[2:])
+	}
+}
+
+func TestCheckStorageContainerIsEmpty(t *testing.T) {
+	if err := checkStorageContainerIsEmpty(testStorageContainerBlobsLister{}); err != nil {
+		t.Fatalf("Expected an empty container not to error but got: %+v", err)
+	}
+
+	nonEmpty := testStorageContainerBlobsLister{
+		blobs: []storage.Blob{
+			{Name: "existing.txt"},
+		},
+	}
+	if err := checkStorageContainerIsEmpty(nonEmpty); err == nil {
+		t.Fatalf("Expected a non-empty container to error but it didn't")
+	}
+}
+
+func TestRetrieveStorageContainer(t *testing.T) {
+	cases := []struct {
+		StatusCode       int
+		ExpectedExists   bool
+		ExpectedRequests int
+	}{
+		{StatusCode: http.StatusOK, ExpectedExists: true, ExpectedRequests: 2},
+		{StatusCode: http.StatusNotFound, ExpectedExists: false, ExpectedRequests: 1},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing a %d response", tc.StatusCode)
+
+		client, err := storage.NewBasicClient("acctestaccount", "YWNjZXNza2V5")
+		if err != nil {
+			t.Fatalf("Error building storage client: %+v", err)
+		}
+		sender := &testStorageCountingSender{statusCode: tc.StatusCode}
+		client.Sender = sender
+
+		blobClient := client.GetBlobService()
+		reference := blobClient.GetContainerReference("vhds")
+		exists, err := retrieveStorageContainer(reference)
+		if err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+		if exists != tc.ExpectedExists {
+			t.Fatalf("Expected exists to be %t but got %t", tc.ExpectedExists, exists)
+		}
+		if len(sender.requests) != tc.ExpectedRequests {
+			t.Fatalf("Expected %d requests but got %d: %+v", tc.ExpectedRequests, len(sender.requests), sender.requests)
+		}
+
+		if exists {
+			if reference.Properties.LeaseState != "available" {
+				t.Fatalf("Expected the lease state to be %q but got %q", "available", reference.Properties.LeaseState)
+			}
+			if reference.Metadata["hello"] != "world" {
+				t.Fatalf("Expected the metadata to be retrieved but got %+v", reference.Metadata)
+			}
+		}
+	}
+}
+
+type testStorageContainerPermissionsClient struct {
+	existing *storage.ContainerPermissions
+	setError error
+}
+
+func (c testStorageContainerPermissionsClient) GetPermissions(options *storage.GetContainerPermissionOptions) (*storage.ContainerPermissions, error) {
+	return c.existing, nil
+}
+
+func (c testStorageContainerPermissionsClient) SetPermissions(permissions storage.ContainerPermissions, options *storage.SetContainerPermissionOptions) error {
+	return c.setError
+}
+
+func TestSetStorageContainerPermissionsAllowingConcurrentCreation(t *testing.T) {
+	conflict := storage.AzureStorageServiceError{
+		StatusCode: http.StatusConflict,
+		Code:       "OperationNotAllowed",
+	}
+
+	cases := []struct {
+		Name           string
+		SetError       error
+		ExistingAccess storage.ContainerAccessType
+		ExpectError    bool
+	}{
+		{
+			Name:        "Success",
+			SetError:    nil,
+			ExpectError: false,
+		},
+		{
+			Name: "Other Error",
+			SetError: storage.AzureStorageServiceError{
+				StatusCode: http.StatusForbidden,
+			},
+			ExpectError: true,
+		},
+		{
+			Name:           "Concurrent Create with Matching Access Type",
+			SetError:       conflict,
+			ExistingAccess: storage.ContainerAccessTypeBlob,
+			ExpectError:    false,
+		},
+		{
+			Name:           "Concurrent Create with Different Access Type",
+			SetError:       conflict,
+			ExistingAccess: storage.ContainerAccessTypeContainer,
+			ExpectError:    true,
+		},
+	}
+
+	for _, v := range cases {
+		t.Logf("[DEBUG] Testing %q", v.Name)
+
+		client := testStorageContainerPermissionsClient{
+			existing: &storage.ContainerPermissions{
+				AccessType: v.ExistingAccess,
+			},
+			setError: v.SetError,
+		}
+		permissions := storage.ContainerPermissions{
+			AccessType: storage.ContainerAccessTypeBlob,
+		}
+
+		err := setStorageContainerPermissionsAllowingConcurrentCreation(client, permissions)
+		if v.ExpectError && err == nil {
+			t.Fatalf("Expected an error but didn't get one")
+		}
+		if !v.ExpectError && err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+	}
+}
+
+type testStorageACLSender struct {
+	policies string
+	requests []*http.Request
+	bodies   []string
+}
+
+func (s *testStorageACLSender) Send(c *storage.Client, req *http.Request) (*http.Response, error) {
+	body := ""
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = string(b)
+	}
+	s.requests = append(s.requests, req)
+	s.bodies = append(s.bodies, body)
+
+	if req.Method == http.MethodGet {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(s.policies)),
+		}, nil
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+func TestStorageContainerACLClientSetAccessTypePreservingPolicies(t *testing.T) {
+	// the Add, Create & List permissions can't be represented by the SDK - so must be sent back as-is
+	policies := `<?xml version="1.0" encoding="utf-8"?><SignedIdentifiers><SignedIdentifier><Id>external</Id><AccessPolicy><Start>2018-01-01T00:00:00.0000000Z</Start><Expiry>2028-01-01T00:00:00.0000000Z</Expiry><Permission>rwdl</Permission></AccessPolicy></SignedIdentifier></SignedIdentifiers>`
+
+	cases := []struct {
+		Name           string
+		AccessType     storage.ContainerAccessType
+		ExpectedHeader string
+	}{
+		{
+			Name:           "Container",
+			AccessType:     storage.ContainerAccessTypeContainer,
+			ExpectedHeader: "container",
+		},
+		{
+			Name:           "Private",
+			AccessType:     storage.ContainerAccessTypePrivate,
+			ExpectedHeader: "",
+		},
+	}
+
+	for _, v := range cases {
+		t.Logf("[DEBUG] Testing %q", v.Name)
+
+		sender := &testStorageACLSender{
+			policies: policies,
+		}
+		storageClient, err := storage.NewBasicClient("acctestacl", "YWNjZXNza2V5")
+		if err != nil {
+			t.Fatalf("Error building the storage client: %+v", err)
+		}
+		storageClient.Sender = sender
+
+		client, err := newStorageContainerACLClient(&storageClient, "acctestacl", "YWNjZXNza2V5", storage.DefaultAPIVersion, "https://acctestacl.blob.core.windows.net/vhds")
+		if err != nil {
+			t.Fatalf("Error building the ACL client: %+v", err)
+		}
+
+		if err := client.setAccessTypePreservingPolicies(v.AccessType); err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+
+		if len(sender.requests) != 2 || sender.requests[1].Method != http.MethodPut {
+			t.Fatalf("Expected a GET followed by a PUT but got %d requests", len(sender.requests))
+		}
+		put := sender.requests[1]
+		if put.URL.Query().Get("comp") != "acl" {
+			t.Fatalf("Expected the ACL to be set but the request was sent to %q", put.URL.String())
+		}
+		if sender.bodies[1] != policies {
+			t.Fatalf("Expected the existing policies to be sent unchanged but got %q", sender.bodies[1])
+		}
+		if !strings.Contains(sender.bodies[1], "<Permission>rwdl</Permission>") {
+			t.Fatalf("Expected the `rwdl` permissions to be preserved but got %q", sender.bodies[1])
+		}
+		if actual := put.Header.Get(storage.ContainerAccessHeader); actual != v.ExpectedHeader {
+			t.Fatalf("Expected the access type header to be %q but got %q", v.ExpectedHeader, actual)
+		}
+	}
+}
+
+func TestStorageContainerACLClientSetAccessTypePreservingPolicies_error(t *testing.T) {
+	storageClient, err := storage.NewBasicClient("acctestacl", "YWNjZXNza2V5")
+	if err != nil {
+		t.Fatalf("Error building the storage client: %+v", err)
+	}
+	sender := &testStorageSequenceSender{
+		statusCodes: []int{http.StatusForbidden},
+	}
+	storageClient.Sender = sender
+
+	client, err := newStorageContainerACLClient(&storageClient, "acctestacl", "YWNjZXNza2V5", storage.DefaultAPIVersion, "https://acctestacl.blob.core.windows.net/vhds")
+	if err != nil {
+		t.Fatalf("Error building the ACL client: %+v", err)
+	}
+
+	err = client.setAccessTypePreservingPolicies(storage.ContainerAccessTypeBlob)
+	if !storageErrorWasStatusCode(err, http.StatusForbidden) {
+		t.Fatalf("Expected a 403 storage error but got: %+v", err)
+	}
+	if sender.requests != 1 {
+		t.Fatalf("Expected the ACL not to be set when the existing policies couldn't be retrieved, but got %d requests", sender.requests)
+	}
+}
+
+func TestSignStorageSharedKeyRequest(t *testing.T) {
+	// the signature must match the one the SDK calculates for the same request
+	sender := &testStorageACLSender{
+		policies: "<SignedIdentifiers />",
+	}
+	storageClient, err := storage.NewBasicClient("acctestacl", "YWNjZXNza2V5")
+	if err != nil {
+		t.Fatalf("Error building the storage client: %+v", err)
+	}
+	storageClient.Sender = sender
+
+	blobClient := storageClient.GetBlobService()
+	reference := blobClient.GetContainerReference("vhds")
+	if _, err := reference.GetPermissions(&storage.GetContainerPermissionOptions{}); err != nil {
+		t.Fatalf("Error retrieving permissions: %+v", err)
+	}
+	permissions := storage.ContainerPermissions{
+		AccessType: storage.ContainerAccessTypeBlob,
+		AccessPolicies: []storage.ContainerAccessPolicy{
+			{
+				ID:         "terraform",
+				StartTime:  time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+				ExpiryTime: time.Date(2028, 1, 1, 0, 0, 0, 0, time.UTC),
+				CanRead:    true,
+			},
+		},
+	}
+	if err := reference.SetPermissions(permissions, &storage.SetContainerPermissionOptions{}); err != nil {
+		t.Fatalf("Error setting permissions: %+v", err)
+	}
+
+	for i, req := range sender.requests {
+		expected := req.Header.Get("Authorization")
+
+		signed, err := http.NewRequest(req.Method, req.URL.String(), strings.NewReader(sender.bodies[i]))
+		if err != nil {
+			t.Fatalf("Error building request: %+v", err)
+		}
+		for k, v := range req.Header {
+			if k != "Authorization" {
+				signed.Header[k] = v
+			}
+		}
+		signStorageSharedKeyRequest(signed, "acctestacl", []byte("accesskey"))
+
+		if actual := signed.Header.Get("Authorization"); actual != expected {
+			t.Fatalf("Expected the %s request to be signed as %q but got %q", req.Method, expected, actual)
+		}
+	}
+}
+
+type testStorageFailingPrimarySender struct {
+	hosts []string
+}
+
+func (s *testStorageFailingPrimarySender) Send(c *storage.Client, req *http.Request) (*http.Response, error) {
+	s.hosts = append(s.hosts, req.URL.Host)
+
+	statusCode := http.StatusOK
+	if !strings.Contains(req.URL.Host, "-secondary.") {
+		statusCode = http.StatusServiceUnavailable
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+func TestStorageSecondaryEndpointSender(t *testing.T) {
+	cases := []struct {
+		Method        string
+		ExpectedHosts []string
+		ExpectedCode  int
+	}{
+		{
+			Method:        http.MethodGet,
+			ExpectedHosts: []string{"example.blob.core.windows.net", "example-secondary.blob.core.windows.net"},
+			ExpectedCode:  http.StatusOK,
+		},
+		{
+			Method:        http.MethodHead,
+			ExpectedHosts: []string{"example.blob.core.windows.net", "example-secondary.blob.core.windows.net"},
+			ExpectedCode:  http.StatusOK,
+		},
+		{
+			Method:        http.MethodPut,
+			ExpectedHosts: []string{"example.blob.core.windows.net"},
+			ExpectedCode:  http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Method)
+
+		inner := &testStorageFailingPrimarySender{}
+		sender := &storageSecondaryEndpointSender{
+			sender: inner,
+		}
+
+		req, err := http.NewRequest(tc.Method, "https://example.blob.core.windows.net/vhds?restype=container", nil)
+		if err != nil {
+			t.Fatalf("Error building request: %+v", err)
+		}
+
+		resp, err := sender.Send(nil, req)
+		if err != nil {
+			t.Fatalf("Error sending request: %+v", err)
+		}
+		if resp.StatusCode != tc.ExpectedCode {
+			t.Fatalf("Expected a %d response but got %d", tc.ExpectedCode, resp.StatusCode)
+		}
+		if !reflect.DeepEqual(inner.hosts, tc.ExpectedHosts) {
+			t.Fatalf("Expected the requests to be sent to %+v but got %+v", tc.ExpectedHosts, inner.hosts)
+		}
+	}
+}
+
+type testStorageThrottledSender struct {
+	throttledRequests int
+	bodies            []string
+}
+
+func (s *testStorageThrottledSender) Send(c *storage.Client, req *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	s.bodies = append(s.bodies, string(body))
+
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}
+	if len(s.bodies) <= s.throttledRequests {
+		resp.StatusCode = http.StatusTooManyRequests
+		resp.Header.Set("Retry-After", "1")
+	}
+	return resp, nil
+}
+
+func TestStorageThrottlingSender(t *testing.T) {
+	inner := &testStorageThrottledSender{
+		throttledRequests: 1,
+	}
+	sender := &storageThrottlingSender{
+		sender:   inner,
+		attempts: 3,
+	}
+
+	req, err := http.NewRequest(http.MethodPut, "https://example.blob.core.windows.net/vhds?restype=container&comp=acl", strings.NewReader("<SignedIdentifiers />"))
+	if err != nil {
+		t.Fatalf("Error building request: %+v", err)
+	}
+
+	start := time.Now()
+	resp, err := sender.Send(nil, req)
+	if err != nil {
+		t.Fatalf("Error sending request: %+v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the request to succeed after being throttled but got %d", resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("Expected the Retry-After of 1s to be honoured but the request completed in %s", elapsed)
+	}
+
+	expected := []string{"<SignedIdentifiers />", "<SignedIdentifiers />"}
+	if !reflect.DeepEqual(inner.bodies, expected) {
+		t.Fatalf("Expected the body to be re-sent with each attempt but got %+v", inner.bodies)
+	}
+}
+
+func TestStorageThrottlingRetryAfter(t *testing.T) {
+	cases := []struct {
+		RetryAfter string
+		Expected   time.Duration
+	}{
+		{RetryAfter: "", Expected: storageThrottlingDefaultDelay},
+		{RetryAfter: "invalid", Expected: storageThrottlingDefaultDelay},
+		{RetryAfter: "0", Expected: 0},
+		{RetryAfter: "10", Expected: 10 * time.Second},
+		{RetryAfter: "3600", Expected: storageThrottlingMaxDelay},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.RetryAfter)
+
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Retry-After", tc.RetryAfter)
+		if actual := storageThrottlingRetryAfter(resp); actual != tc.Expected {
+			t.Fatalf("Expected a delay of %s but got %s", tc.Expected, actual)
+		}
+	}
+}
+
+func TestArmClientClaimStorageContainer(t *testing.T) {
+	client := &ArmClient{}
+
+	if err := client.claimStorageContainer("acctestaccount", "vhds"); err != nil {
+		t.Fatalf("Expected the first claim to succeed but got: %+v", err)
+	}
+
+	if err := client.claimStorageContainer("acctestaccount", "other"); err != nil {
+		t.Fatalf("Expected a claim for a different container to succeed but got: %+v", err)
+	}
+
+	if err := client.claimStorageContainer("otheraccount", "vhds"); err != nil {
+		t.Fatalf("Expected a claim for a different account to succeed but got: %+v", err)
+	}
+
+	if err := client.claimStorageContainer("AccTestAccount", "VHDS"); err == nil {
+		t.Fatalf("Expected a case-insensitive duplicate claim to fail")
+	}
+
+	client.releaseStorageContainer("acctestaccount", "vhds")
+	if err := client.claimStorageContainer("acctestaccount", "vhds"); err != nil {
+		t.Fatalf("Expected a claim after release to succeed but got: %+v", err)
+	}
+
+	client.trackStorageContainer("acctestaccount", "tracked")
+	client.trackStorageContainer("acctestaccount", "tracked")
+	if err := client.claimStorageContainer("acctestaccount", "tracked"); err == nil {
+		t.Fatalf("Expected a claim for a container already read by another resource to fail")
+	}
+}
+
+type testStorageFailingPermissionsTransport struct{}
+
+func (t *testStorageFailingPermissionsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	statusCode := http.StatusCreated
+	if req.URL.Query().Get("comp") == "acl" {
+		statusCode = http.StatusForbidden
+	}
+
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func TestResourceArmStorageContainerCreate_releasesClaimOnFailure(t *testing.T) {
+	storageKeyCacheMu.Lock()
+	storageKeyCache["mock-resources/acctestclaim"] = "YWNjZXNza2V5"
+	storageKeyCacheMu.Unlock()
+	defer func() {
+		storageKeyCacheMu.Lock()
+		delete(storageKeyCache, "mock-resources/acctestclaim")
+		storageKeyCacheMu.Unlock()
+	}()
+
+	client := &ArmClient{
+		environment:       azure.PublicCloud,
+		storageHTTPClient: &http.Client{Transport: &testStorageFailingPermissionsTransport{}},
+		StopContext:       context.Background(),
+	}
+
+	raw, err := config.NewRawConfig(map[string]interface{}{
+		"name":                 "vhds",
+		"resource_group_name":  "mock-resources",
+		"storage_account_name": "acctestclaim",
+	})
+	if err != nil {
+		t.Fatalf("Error building the config: %+v", err)
+	}
+
+	// the diff is applied (rather than calling Create directly) so that the timeouts are populated
+	r := resourceArmStorageContainer()
+	diff, err := r.Diff(nil, terraform.NewResourceConfig(raw), client)
+	if err != nil {
+		t.Fatalf("Error building the diff: %+v", err)
+	}
+
+	// the container is created, but setting its permissions fails
+	if _, err := r.Apply(nil, diff, client); err == nil {
+		t.Fatalf("Expected an error but didn't get one")
+	}
+
+	if err := client.claimStorageContainer("acctestclaim", "vhds"); err != nil {
+		t.Fatalf("Expected the claim to be released when creation fails but got: %+v", err)
+	}
+}
+
+func TestArmClientStorageContainerAccessTypeOrDefault(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		ProviderDefault string
+		Configured      string
+		Expected        string
+	}{
+		{
+			Name:     "No Provider Default or Configured Value",
+			Expected: "private",
+		},
+		{
+			Name:            "Provider Default",
+			ProviderDefault: "blob",
+			Expected:        "blob",
+		},
+		{
+			Name:       "Configured Value",
+			Configured: "container",
+			Expected:   "container",
+		},
+		{
+			Name:            "Configured Value takes precedence over Provider Default",
+			ProviderDefault: "blob",
+			Configured:      "private",
+			Expected:        "private",
+		},
+	}
+
+	for _, v := range testCases {
+		t.Logf("[DEBUG] Testing %q", v.Name)
+
+		client := &ArmClient{
+			defaultStorageContainerAccessType: v.ProviderDefault,
+		}
+		actual := client.storageContainerAccessTypeOrDefault(v.Configured)
+		if actual != v.Expected {
+			t.Fatalf("Expected %q but got %q", v.Expected, actual)
+		}
+	}
+}
+
+func TestArmClientCheckStorageContainerNameMatchesPolicy(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Regex         string
+		ContainerName string
+		ExpectError   bool
+	}{
+		{
+			Name:          "No Regex",
+			ContainerName: "vhds",
+		},
+		{
+			Name:          "Conforming Name",
+			Regex:         "^team-(alpha|beta)-",
+			ContainerName: "team-alpha-logs",
+		},
+		{
+			Name:          "Non-Conforming Name",
+			Regex:         "^team-(alpha|beta)-",
+			ContainerName: "logs",
+			ExpectError:   true,
+		},
+		{
+			Name:          "Invalid Regex",
+			Regex:         "^team-(",
+			ContainerName: "team-alpha-logs",
+			ExpectError:   true,
+		},
+	}
+
+	for _, v := range testCases {
+		t.Logf("[DEBUG] Testing %q", v.Name)
+
+		client := &ArmClient{
+			storageContainerNameRegex: v.Regex,
+		}
+		err := client.checkStorageContainerNameMatchesPolicy(v.ContainerName)
+		if v.ExpectError && err == nil {
+			t.Fatalf("Expected an error but didn't get one")
+		}
+		if !v.ExpectError && err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+	}
+}
+
+func TestArmClientCheckStorageContainerAccessTypeIsAllowed(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Allowed     []string
+		AccessType  string
+		ExpectError bool
+	}{
+		{
+			Name:       "No Allowed Access Types",
+			AccessType: "container",
+		},
+		{
+			Name:       "Allowed Access Type",
+			Allowed:    []string{"private"},
+			AccessType: "private",
+		},
+		{
+			Name:       "Allowed Access Type in a different case",
+			Allowed:    []string{"Private", "blob"},
+			AccessType: "private",
+		},
+		{
+			Name:        "Public Access Type Rejected",
+			Allowed:     []string{"private"},
+			AccessType:  "blob",
+			ExpectError: true,
+		},
+		{
+			Name:        "Container Access Type Rejected",
+			Allowed:     []string{"private", "blob"},
+			AccessType:  "container",
+			ExpectError: true,
+		},
+	}
+
+	for _, v := range testCases {
+		t.Logf("[DEBUG] Testing %q", v.Name)
+
+		client := &ArmClient{
+			allowedStorageContainerAccessTypes: v.Allowed,
+		}
+		err := client.checkStorageContainerAccessTypeIsAllowed(v.AccessType)
+		if v.ExpectError && err == nil {
+			t.Fatalf("Expected an error but didn't get one")
+		}
+		if !v.ExpectError && err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+	}
+}
+
+func TestStorageContainerLeaseIsActive(t *testing.T) {
+	cases := []struct {
+		Name       string
+		Properties storage.ContainerProperties
+		Expected   bool
+	}{
+		{
+			Name: "Available",
+			Properties: storage.ContainerProperties{
+				LeaseStatus: "unlocked",
+				LeaseState:  "available",
+			},
+			Expected: false,
+		},
+		{
+			Name: "Infinite Lease",
+			Properties: storage.ContainerProperties{
+				LeaseStatus:   "locked",
+				LeaseState:    "leased",
+				LeaseDuration: "infinite",
+			},
+			Expected: true,
+		},
+		{
+			Name: "Fixed Lease",
+			Properties: storage.ContainerProperties{
+				LeaseStatus:   "locked",
+				LeaseState:    "leased",
+				LeaseDuration: "fixed",
+			},
+			Expected: true,
+		},
+		{
+			Name: "Breaking",
+			Properties: storage.ContainerProperties{
+				LeaseStatus: "locked",
+				LeaseState:  "breaking",
+			},
+			Expected: true,
+		},
+		{
+			Name: "Broken",
+			Properties: storage.ContainerProperties{
+				LeaseStatus: "unlocked",
+				LeaseState:  "broken",
+			},
+			Expected: false,
+		},
+		{
+			Name: "Expired",
+			Properties: storage.ContainerProperties{
+				LeaseStatus: "unlocked",
+				LeaseState:  "expired",
+			},
+			Expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Logf("[DEBUG] Testing %q", tc.Name)
+
+		if actual := storageContainerLeaseIsActive(tc.Properties); actual != tc.Expected {
+			t.Fatalf("Expected the lease to be active %t but got %t", tc.Expected, actual)
+		}
+	}
+}
+
+func TestStorageContainerAccessTypeFromPermissions(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Permissions *storage.ContainerPermissions
+		Error       error
+		Expected    string
+		ExpectError bool
+	}{
+		{
+			Name:        "Private",
+			Permissions: &storage.ContainerPermissions{AccessType: storage.ContainerAccessType("")},
+			Expected:    "private",
+		},
+		{
+			Name:        "Blob",
+			Permissions: &storage.ContainerPermissions{AccessType: storage.ContainerAccessTypeBlob},
+			Expected:    "blob",
+		},
+		{
+			Name:        "Container",
+			Permissions: &storage.ContainerPermissions{AccessType: storage.ContainerAccessTypeContainer},
+			Expected:    "container",
+		},
+		{
+			Name:     "Forbidden on Permissions",
+			Error:    storage.AzureStorageServiceError{StatusCode: 403, Code: "AuthorizationFailure"},
+			Expected: "private",
+		},
+		{
+			Name:        "Other Error",
+			Error:       storage.AzureStorageServiceError{StatusCode: 500, Code: "InternalError"},
+			ExpectError: true,
+		},
+	}
+
+	for _, v := range testCases {
+		t.Logf("[DEBUG] Testing %q", v.Name)
+
+		actual, err := storageContainerAccessTypeFromPermissions(v.Permissions, v.Error)
+		if v.ExpectError {
+			if err == nil {
+				t.Fatalf("Expected an error but didn't get one")
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Expected no error but got: %+v", err)
+		}
+
+		if actual != v.Expected {
+			t.Fatalf("Expected %q but got %q", v.Expected, actual)
+		}
+	}
+}
+
+func testAccAzureRMStorageContainer_basic(rInt int, rString string, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+    name = "acctestRG-%d"
+    location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+    name                     = "acctestacc%s"
+    resource_group_name      = "${azurerm_resource_group.test.name}"
+    location                 = "${azurerm_resource_group.test.location}"
+    account_tier             = "Standard"
     account_replication_type = "LRS"
 
     tags {
@@ -300,3 +2452,179 @@ resource "azurerm_storage_container" "test" {
 }
 `, rInt, location, rString)
 }
+
+func testAccAzureRMStorageContainer_metadata(rInt int, rString string, location string, metadataKey string, ignoreChanges bool) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+  name                     = "acctestacc%s"
+  resource_group_name      = "${azurerm_resource_group.test.name}"
+  location                 = "${azurerm_resource_group.test.location}"
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_container" "test" {
+  name                    = "vhds"
+  resource_group_name     = "${azurerm_resource_group.test.name}"
+  storage_account_name    = "${azurerm_storage_account.test.name}"
+  container_access_type   = "private"
+  ignore_metadata_changes = %t
+
+  metadata {
+    %s = "world"
+  }
+}
+`, rInt, location, rString, ignoreChanges, metadataKey)
+}
+
+func testAccAzureRMStorageContainer_costTags(rInt int, rString string, location string, costCenter string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+  name                     = "acctestacc%s"
+  resource_group_name      = "${azurerm_resource_group.test.name}"
+  location                 = "${azurerm_resource_group.test.location}"
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_container" "test" {
+  name                  = "vhds"
+  resource_group_name   = "${azurerm_resource_group.test.name}"
+  storage_account_name  = "${azurerm_storage_account.test.name}"
+  container_access_type = "private"
+
+  metadata {
+    hello = "world"
+  }
+
+  cost_tags {
+    center = "%s"
+  }
+}
+`, rInt, location, rString, costCenter)
+}
+
+func testAccAzureRMStorageContainer_triggers(rInt int, rString string, location string, trigger string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+  name                     = "acctestacc%s"
+  resource_group_name      = "${azurerm_resource_group.test.name}"
+  location                 = "${azurerm_resource_group.test.location}"
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_container" "test" {
+  name                  = "vhds"
+  resource_group_name   = "${azurerm_resource_group.test.name}"
+  storage_account_name  = "${azurerm_storage_account.test.name}"
+  container_access_type = "private"
+
+  triggers {
+    refresh = "%s"
+  }
+}
+`, rInt, location, rString, trigger)
+}
+
+func testAccAzureRMStorageContainer_template(rInt int, rString string, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_storage_account" "test" {
+  name                     = "acctestacc%s"
+  resource_group_name      = "${azurerm_resource_group.test.name}"
+  location                 = "${azurerm_resource_group.test.location}"
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+`, rInt, location, rString)
+}
+
+func testAccAzureRMStorageContainer_requireEmptyOnAdopt(rInt int, rString string, location string) string {
+	template := testAccAzureRMStorageContainer_template(rInt, rString, location)
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_storage_container" "test" {
+  name                   = "vhds"
+  resource_group_name    = "${azurerm_resource_group.test.name}"
+  storage_account_name   = "${azurerm_storage_account.test.name}"
+  container_access_type  = "private"
+  require_empty_on_adopt = true
+}
+`, template)
+}
+
+func testAccAzureRMStorageContainer_createAccountIfMissing(rInt int, rString string, location string) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_storage_container" "test" {
+  name                  = "vhds"
+  resource_group_name   = "${azurerm_resource_group.test.name}"
+  storage_account_name  = "acctestacc%s"
+  container_access_type = "private"
+
+  create_account_if_missing {
+    location                 = "${azurerm_resource_group.test.location}"
+    account_tier             = "Standard"
+    account_replication_type = "LRS"
+  }
+}
+`, rInt, location, rString)
+}
+
+func testAccAzureRMStorageContainer_accountMove(rInt int, rString string, location string, account string, allowMove bool) string {
+	return fmt.Sprintf(`
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%d"
+  location = "%s"
+}
+
+resource "azurerm_storage_account" "first" {
+  name                     = "acctestacc%s"
+  resource_group_name      = "${azurerm_resource_group.test.name}"
+  location                 = "${azurerm_resource_group.test.location}"
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_account" "second" {
+  name                     = "acctestacc2%s"
+  resource_group_name      = "${azurerm_resource_group.test.name}"
+  location                 = "${azurerm_resource_group.test.location}"
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_container" "test" {
+  name                  = "vhds"
+  resource_group_name   = "${azurerm_resource_group.test.name}"
+  storage_account_name  = "${azurerm_storage_account.%s.name}"
+  container_access_type = "private"
+  allow_account_move    = %t
+}
+`, rInt, location, rString, rString, account, allowMove)
+}